@@ -0,0 +1,315 @@
+package wpasupplicant
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+)
+
+// Access point event names.
+const (
+	EventAPEnabled         = "AP-ENABLED"
+	EventAPDisabled        = "AP-DISABLED"
+	EventAPStaConnected    = "AP-STA-CONNECTED"
+	EventAPStaDisconnected = "AP-STA-DISCONNECTED"
+)
+
+var (
+	// ErrAPStarted is returned by StartAccessPoint when an access point
+	// started on the connection is still running.
+	ErrAPStarted = errors.New("access point already started")
+
+	// ErrAPNotStarted is returned by StopAccessPoint when no access point
+	// was started on the connection.
+	ErrAPNotStarted = errors.New("access point not started")
+
+	// ErrAPDisabled is returned by StartAccessPoint when wpa_supplicant
+	// failed to start the access point.
+	ErrAPDisabled = errors.New("access point disabled")
+)
+
+// APSecurity is the security of an access point.
+type APSecurity int
+
+const (
+	// APSecurityAuto uses WPA3 on 6 GHz, WPA2 elsewhere, or no security
+	// if there is no passphrase.
+	APSecurityAuto APSecurity = iota
+
+	// APSecurityOpen uses no security.
+	APSecurityOpen
+
+	// APSecurityWPA2 uses WPA2-Personal (PSK).
+	APSecurityWPA2
+
+	// APSecurityWPA3 uses WPA3-Personal (SAE) with management frame
+	// protection required.
+	APSecurityWPA3
+
+	// APSecurityWPA2WPA3 accepts both WPA2 and WPA3 clients.
+	APSecurityWPA2WPA3
+)
+
+// APConfig describes an access point started by StartAccessPoint.
+type APConfig struct {
+	SSID string
+
+	// Passphrase is the WPA passphrase, 8 to 63 characters.
+	Passphrase string
+
+	// Band and Channel select the operating channel.  The band defaults
+	// to 2.4 GHz, and the channel to 6 on 2.4 GHz, 36 on 5 GHz and 5 on
+	// 6 GHz.
+	Band    Band
+	Channel int
+
+	// Hidden hides the SSID from beacons.
+	Hidden bool
+
+	Security APSecurity
+}
+
+// defaultAPChannels are the channels used when APConfig.Channel is zero.
+var defaultAPChannels = map[Band]int{
+	Band2GHz: 6,
+	Band5GHz: 36,
+	Band6GHz: 5,
+}
+
+// variables validates the config and returns the network variables which
+// configure it, in the order they must be set.
+func (c APConfig) variables() ([][2]interface{}, error) {
+	if len(c.SSID) == 0 || len(c.SSID) > 32 {
+		return nil, errors.New("SSID must be 1 to 32 bytes")
+	}
+
+	band := c.Band
+	if band == BandUnknown {
+		band = Band2GHz
+	}
+	channel := c.Channel
+	if channel == 0 {
+		channel = defaultAPChannels[band]
+	}
+	freq, err := ChannelFrequency(band, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	security := c.Security
+	if security == APSecurityAuto {
+		switch {
+		case c.Passphrase == "":
+			security = APSecurityOpen
+		case band == Band6GHz:
+			security = APSecurityWPA3
+		default:
+			security = APSecurityWPA2
+		}
+	}
+	if band == Band6GHz && security != APSecurityWPA3 {
+		return nil, errors.New("6 GHz access points require WPA3")
+	}
+	if security == APSecurityOpen && c.Passphrase != "" {
+		return nil, errors.New("open access point with a passphrase")
+	}
+	if security != APSecurityOpen && (len(c.Passphrase) < 8 || len(c.Passphrase) > 63) {
+		return nil, errors.New("passphrase must be 8 to 63 characters")
+	}
+
+	vars := [][2]interface{}{
+		{"ssid", []byte(c.SSID)},
+		{"mode", 2},
+		{"frequency", freq},
+	}
+
+	switch security {
+	case APSecurityOpen:
+		vars = append(vars, [2]interface{}{"key_mgmt", "NONE"})
+	case APSecurityWPA2:
+		vars = append(vars,
+			[2]interface{}{"proto", "RSN"},
+			[2]interface{}{"key_mgmt", "WPA-PSK"},
+			[2]interface{}{"pairwise", "CCMP"},
+			[2]interface{}{"group", "CCMP"},
+			[2]interface{}{"psk", c.Passphrase},
+		)
+	case APSecurityWPA3:
+		vars = append(vars,
+			[2]interface{}{"proto", "RSN"},
+			[2]interface{}{"key_mgmt", "SAE"},
+			[2]interface{}{"pairwise", "CCMP"},
+			[2]interface{}{"group", "CCMP"},
+			[2]interface{}{"ieee80211w", 2},
+			[2]interface{}{"sae_password", c.Passphrase},
+		)
+	case APSecurityWPA2WPA3:
+		vars = append(vars,
+			[2]interface{}{"proto", "RSN"},
+			[2]interface{}{"key_mgmt", "WPA-PSK SAE"},
+			[2]interface{}{"pairwise", "CCMP"},
+			[2]interface{}{"group", "CCMP"},
+			[2]interface{}{"ieee80211w", 1},
+			[2]interface{}{"psk", c.Passphrase},
+		)
+	default:
+		return nil, errors.New("unknown access point security")
+	}
+
+	if c.Hidden {
+		vars = append(vars, [2]interface{}{"ignore_broadcast_ssid", 1})
+	}
+
+	return vars, nil
+}
+
+// apState is what StartAccessPoint changed, so that StopAccessPoint can
+// undo it.
+type apState struct {
+	// network is the ID of the access point network.
+	network int
+
+	// previous is the ID of the network which was current, or -1.
+	previous int
+
+	// enabled are the IDs of the networks which were enabled.
+	enabled []int
+}
+
+// StationEvent is an AP-STA-CONNECTED or AP-STA-DISCONNECTED event.
+type StationEvent struct {
+	// Event is EventAPStaConnected or EventAPStaDisconnected.
+	Event string
+
+	// Address is the MAC address of the station.
+	Address net.HardwareAddr
+
+	// P2PDevAddr is the P2P device address of the station, if it is a
+	// P2P client.
+	P2PDevAddr net.HardwareAddr
+}
+
+// Connected returns true if the station connected, false if it
+// disconnected.
+func (e *StationEvent) Connected() bool {
+	return e.Event == EventAPStaConnected
+}
+
+// ParseStationEvent returns the StationEvent for e, or false if e isn't a
+// station event.
+func ParseStationEvent(e WPAEvent) (*StationEvent, bool) {
+	if e.Event != EventAPStaConnected && e.Event != EventAPStaDisconnected {
+		return nil, false
+	}
+
+	s := &StationEvent{Event: e.Event}
+	if f := splitEventFields(e.Line); len(f) > 1 {
+		s.Address, _ = net.ParseMAC(f[1])
+	}
+	s.P2PDevAddr, _ = net.ParseMAC(e.Arguments["p2p_dev_addr"])
+
+	return s, true
+}
+
+func (uc *unixgram) StartAccessPoint(ctx context.Context, c APConfig) error {
+	vars, err := c.variables()
+	if err != nil {
+		return err
+	}
+
+	uc.apLock.Lock()
+	defer uc.apLock.Unlock()
+
+	if uc.ap != nil {
+		return ErrAPStarted
+	}
+
+	networks, err := uc.ListNetworks()
+	if err != nil {
+		return err
+	}
+	state := &apState{previous: -1}
+	for _, n := range networks {
+		id, err := strconv.Atoi(n.NetworkID())
+		if err != nil {
+			continue
+		}
+
+		// A TEMP-DISABLED network is enabled, and must be enabled
+		// again too.
+		enabled := true
+		for _, f := range n.Flags() {
+			switch f {
+			case "CURRENT":
+				state.previous = id
+			case "DISABLED":
+				enabled = false
+			}
+		}
+		if enabled {
+			state.enabled = append(state.enabled, id)
+		}
+	}
+
+	if state.network, err = uc.addNetwork(vars); err != nil {
+		return err
+	}
+
+	events, unsubscribe := uc.Subscribe()
+	defer unsubscribe()
+
+	if err := uc.SelectNetwork(state.network); err != nil {
+		uc.restoreNetworks(state)
+		return err
+	}
+
+	err = await(ctx, events, func(e WPAEvent) (bool, error) {
+		switch e.Event {
+		case EventAPEnabled:
+			return true, nil
+		case EventAPDisabled:
+			return true, ErrAPDisabled
+		}
+		return false, nil
+	})
+	if err != nil {
+		uc.restoreNetworks(state)
+		return err
+	}
+
+	uc.ap = state
+	return nil
+}
+
+func (uc *unixgram) StopAccessPoint() error {
+	uc.apLock.Lock()
+	defer uc.apLock.Unlock()
+
+	if uc.ap == nil {
+		return ErrAPNotStarted
+	}
+
+	err := uc.restoreNetworks(uc.ap)
+	uc.ap = nil
+	return err
+}
+
+// restoreNetworks removes the access point network and restores the
+// networks which were selected and enabled before it was started.
+func (uc *unixgram) restoreNetworks(state *apState) error {
+	err := uc.RemoveNetwork(state.network)
+
+	if state.previous != -1 {
+		if serr := uc.SelectNetwork(state.previous); serr != nil && err == nil {
+			err = serr
+		}
+	}
+	for _, id := range state.enabled {
+		if eerr := uc.EnableNetwork(id); eerr != nil && err == nil {
+			err = eerr
+		}
+	}
+
+	return err
+}
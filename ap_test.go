@@ -0,0 +1,139 @@
+package wpasupplicant_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-laeo/wpasupplicant"
+	"github.com/go-laeo/wpasupplicant/wpatest"
+)
+
+func TestAccessPoint(t *testing.T) {
+	srv, conn := connect(t)
+	srv.SetBSSs(wpatest.BSS{BSSID: "02:00:00:00:01:00", SSID: "home", Frequency: 2412, Signal: -40, Flags: "[WPA2-PSK-CCMP][ESS]"})
+
+	home, err := conn.AddNetwork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.SetNetwork(home, "ssid", "home"); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.SelectNetwork(home); err != nil {
+		t.Fatal(err)
+	}
+
+	events, unsubscribe := conn.Subscribe()
+	defer unsubscribe()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	err = conn.StartAccessPoint(ctx, wpasupplicant.APConfig{
+		SSID:       "setup",
+		Passphrase: "provision-me",
+		Band:       wpasupplicant.Band5GHz,
+		Hidden:     true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.StartAccessPoint(ctx, wpasupplicant.APConfig{SSID: "again"}); err != wpasupplicant.ErrAPStarted {
+		t.Errorf("second start: got %v", err)
+	}
+
+	vars := srv.Network(1)
+	for k, v := range map[string]string{
+		"mode":                  "2",
+		"frequency":             "5180",
+		"key_mgmt":              "WPA-PSK",
+		"proto":                 "RSN",
+		"psk":                   `"provision-me"`,
+		"ignore_broadcast_ssid": "1",
+	} {
+		if vars[k] != v {
+			t.Errorf("%s: got %q, expect %q", k, vars[k], v)
+		}
+	}
+
+	srv.Emit("AP-STA-CONNECTED 02:00:00:00:03:00")
+	s, ok := wpasupplicant.ParseStationEvent(waitEvent(t, events, wpasupplicant.EventAPStaConnected))
+	if !ok || !s.Connected() || s.Address.String() != "02:00:00:00:03:00" {
+		t.Errorf("got %+v", s)
+	}
+
+	if err := conn.StopAccessPoint(); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, events, wpasupplicant.EventAPDisabled)
+
+	networks, err := conn.ListNetworks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(networks) != 1 || networks[0].SSID() != "home" || len(networks[0].Flags()) != 1 || networks[0].Flags()[0] != "CURRENT" {
+		t.Errorf("client network not restored: %+v", networks)
+	}
+}
+
+func TestAccessPointRestoresTempDisabled(t *testing.T) {
+	srv, conn := connect(t)
+	srv.SetBSSs(wpatest.BSS{BSSID: "02:00:00:00:01:00", SSID: "home", Frequency: 2412, Signal: -40, Flags: "[ESS]"})
+
+	for _, ssid := range []string{"home", "office", "old"} {
+		id, err := conn.AddNetwork()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := conn.SetNetwork(id, "ssid", ssid); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv.Handle("LIST_NETWORKS", func(string) (string, []string) {
+		return "network id / ssid / bssid / flags\n" +
+			"0\thome\tany\t[CURRENT]\n" +
+			"1\toffice\tany\t[TEMP-DISABLED]\n" +
+			"2\told\tany\t[DISABLED]\n", nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := conn.StartAccessPoint(ctx, wpasupplicant.APConfig{SSID: "setup"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.StopAccessPoint(); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := map[string]bool{}
+	for _, c := range srv.Commands() {
+		sent[c] = true
+	}
+	for cmd, expect := range map[string]bool{
+		"SELECT_NETWORK 0": true,
+		"ENABLE_NETWORK 0": true,
+		"ENABLE_NETWORK 1": true,
+		"ENABLE_NETWORK 2": false,
+	} {
+		if sent[cmd] != expect {
+			t.Errorf("%s: sent %t, expect %t", cmd, sent[cmd], expect)
+		}
+	}
+}
+
+func TestAccessPointConfig(t *testing.T) {
+	_, conn := connect(t)
+
+	for _, c := range []wpasupplicant.APConfig{
+		{SSID: ""},
+		{SSID: "short", Passphrase: "1234567"},
+		{SSID: "open", Passphrase: "12345678", Security: wpasupplicant.APSecurityOpen},
+		{SSID: "wpa2", Passphrase: "12345678", Band: wpasupplicant.Band6GHz, Security: wpasupplicant.APSecurityWPA2},
+		{SSID: "channel", Channel: 15},
+	} {
+		if err := conn.StartAccessPoint(context.Background(), c); err == nil {
+			t.Errorf("%+v: expected an error", c)
+		}
+	}
+}
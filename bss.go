@@ -0,0 +1,115 @@
+package wpasupplicant
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownBSS is returned by BSS for access points which aren't in
+// wpa_supplicant's BSS table.
+var ErrUnknownBSS = errors.New("unknown BSS")
+
+// BSS is an entry of wpa_supplicant's BSS table, as reported by the BSS
+// command.
+type BSS struct {
+	BSSID net.HardwareAddr
+	SSID  string
+
+	// Frequency is the frequency, in MHz, of the primary channel.
+	Frequency int
+
+	// Channel is the channel of the BSS, including its width, which is
+	// determined from the HT, VHT, HE and EHT Operation elements.
+	Channel Channel
+
+	// Level and Noise are the signal and noise levels, in dBm.
+	Level int
+	Noise int
+
+	// Age is the number of seconds since the BSS was last seen.
+	Age int
+
+	// Flags are the flags as in ScanResult.Flags, and Security the
+	// security configurations parsed from them.
+	Flags    []string
+	Security []Security
+
+	// IEs are the information elements of the last beacon or probe
+	// response.
+	IEs []byte
+
+	// Vars are all the variables reported by wpa_supplicant, including
+	// the ones parsed into the fields above.
+	Vars map[string]string
+}
+
+func (uc *unixgram) BSS(bssid net.HardwareAddr) (*BSS, error) {
+	resp, err := uc.cmd("BSS " + bssid.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return parseBSS(strings.NewReader(string(resp)))
+}
+
+// parseBSS parses the key=value output of the BSS command.
+func parseBSS(resp io.Reader) (*BSS, error) {
+	b := &BSS{Vars: map[string]string{}}
+
+	s := bufio.NewScanner(resp)
+	s.Buffer(nil, 64*1024)
+	for s.Scan() {
+		ln := s.Text()
+		i := strings.IndexByte(ln, '=')
+		if i < 0 {
+			continue
+		}
+		key, v := ln[:i], ln[i+1:]
+		b.Vars[key] = v
+
+		var err error
+		switch key {
+		case "bssid":
+			b.BSSID, err = net.ParseMAC(v)
+		case "ssid":
+			b.SSID = v
+		case "freq":
+			b.Frequency, err = strconv.Atoi(v)
+		case "level":
+			b.Level, err = strconv.Atoi(v)
+		case "noise":
+			b.Noise, err = strconv.Atoi(v)
+		case "age":
+			b.Age, err = strconv.Atoi(v)
+		case "flags":
+			if len(v) >= 2 && v[0] == '[' && v[len(v)-1] == ']' {
+				b.Flags = strings.Split(v[1:len(v)-1], "][")
+			}
+		case "ie":
+			b.IEs, err = hex.DecodeString(v)
+		}
+		if err != nil {
+			return nil, &ParseError{Line: ln, Err: err}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	// wpa_supplicant replies with nothing for unknown BSSs.
+	if b.BSSID == nil {
+		return nil, ErrUnknownBSS
+	}
+
+	b.Security = parseSecurityFlags(b.Flags)
+	if ch, ok := ChannelFromFrequency(b.Frequency); ok {
+		ch.Width = channelWidth(ch.Band, b.IEs)
+		b.Channel = ch
+	}
+	return b, nil
+}
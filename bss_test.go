@@ -0,0 +1,47 @@
+package wpasupplicant_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/go-laeo/wpasupplicant"
+	"github.com/go-laeo/wpasupplicant/wpatest"
+)
+
+func TestBSS(t *testing.T) {
+	srv, conn := connect(t)
+	srv.SetBSSs(
+		wpatest.BSS{BSSID: "02:00:00:00:01:00", SSID: "home", Frequency: 2412, Signal: -40, Flags: "[WPA2-PSK-CCMP][ESS]"},
+		wpatest.BSS{BSSID: "02:00:00:00:02:00", SSID: "home", Frequency: 5180, Signal: -60, Flags: "[WPA2-PSK-CCMP][ESS]", IEs: "c005012a000000"},
+		wpatest.BSS{BSSID: "02:00:00:00:03:00", SSID: "cafe", Frequency: 5975, Signal: -70, Flags: "[ESS]"},
+	)
+
+	results, errs := conn.ScanResults()
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if ch := results[0].Channel(); ch.Band != wpasupplicant.Band2GHz || ch.Number != 1 {
+		t.Errorf("got %v", ch)
+	}
+	if r := results.FilterBand(wpasupplicant.Band5GHz); len(r) != 1 || r[0].BSSID().String() != "02:00:00:00:02:00" {
+		t.Errorf("got %+v", r)
+	}
+	if r := results.FilterBand(wpasupplicant.Band6GHz); len(r) != 1 || !r[0].Channel().PSC() {
+		t.Errorf("got %+v", r)
+	}
+
+	bssid, _ := net.ParseMAC("02:00:00:00:02:00")
+	bss, err := conn.BSS(bssid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bss.SSID != "home" || bss.Level != -60 || bss.Channel.Number != 36 || bss.Channel.Width != wpasupplicant.Width80 || bss.Security[0].KeyMgmt != wpasupplicant.PSK {
+		t.Errorf("got %+v", bss)
+	}
+
+	bssid, _ = net.ParseMAC("02:00:00:00:09:00")
+	if _, err := conn.BSS(bssid); !errors.Is(err, wpasupplicant.ErrUnknownBSS) {
+		t.Errorf("expected ErrUnknownBSS, got %v", err)
+	}
+}
@@ -0,0 +1,326 @@
+package wpasupplicant
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNotSupported is returned for network configs which the device can't
+// do.  See NetworkConfig.CheckCapabilities.
+var ErrNotSupported = errors.New("not supported by the device")
+
+// capabilityNames are the GET_CAPABILITY fields queried by Capabilities,
+// in order.
+var capabilityNames = []string{
+	"eap", "pairwise", "group", "key_mgmt", "proto", "auth_alg", "modes",
+	"channels", "freq", "tdls", "erp", "fips", "acs", "pmf", "sae", "dpp",
+}
+
+// checkedKeyMgmt are the key management types GET_CAPABILITY reports.
+// Others, e.g. WPA-PSK-SHA256, are implied by them.
+const checkedKeyMgmt = IEEE8021X | PSK | FT_IEEE8021X | FT_PSK | SAE | FT_SAE |
+	IEEE8021X_SUITE_B | IEEE8021X_SUITE_B_192 | FILS_SHA256 | FILS_SHA384 |
+	FT_FILS_SHA256 | FT_FILS_SHA384 | OWE | DPP | FT_IEEE8021X_SHA384
+
+// SupportedChannel is a channel the device can use.
+type SupportedChannel struct {
+	Channel
+
+	// NoIR is true if the channel may only be used passively: no
+	// probing, and no access point.
+	NoIR bool
+
+	// DFS is true if radar detection is required on the channel.
+	DFS bool
+}
+
+// Capabilities are the capabilities of the device and of wpa_supplicant,
+// from GET_CAPABILITY.
+type Capabilities struct {
+	// EAP are the supported EAP methods, e.g. "TLS" or "AKA'".
+	EAP []EAPMethod
+
+	// Pairwise and Group are the supported ciphers, and KeyMgmt the
+	// supported key management types.
+	Pairwise Cipher
+	Group    Cipher
+	KeyMgmt  KeyMgmt
+
+	// Proto are the supported protocols ("RSN", "WPA"), AuthAlg the
+	// authentication algorithms ("OPEN", "SHARED", "LEAP") and Modes the
+	// operating modes ("IBSS", "AP", "MESH").
+	Proto   []string
+	AuthAlg []string
+	Modes   []string
+
+	// Channels are the enabled channels, by increasing frequency.
+	Channels []SupportedChannel
+
+	// TDLS is "EXTERNAL" or "INTERNAL", if TDLS is supported.
+	TDLS string
+
+	ERP  bool
+	FIPS bool
+	ACS  bool
+	PMF  bool
+
+	// SAEH2E and SAEPK are true if SAE hash-to-element and public key
+	// are supported.
+	SAEH2E bool
+	SAEPK  bool
+
+	// DPPVersion is the supported DPP version, or 0.
+	DPPVersion int
+
+	// Unknown are the fields wpa_supplicant didn't report, because it is
+	// too old or built without them.  Their values are the zero values.
+	Unknown []string
+}
+
+// Known returns true if wpa_supplicant reported the GET_CAPABILITY field
+// name, e.g. "pmf".
+func (c *Capabilities) Known(name string) bool {
+	for _, u := range c.Unknown {
+		if u == name {
+			return false
+		}
+	}
+	return true
+}
+
+// Bands returns the bands of the supported channels.
+func (c *Capabilities) Bands() []Band {
+	var bands []Band
+	for _, ch := range c.Channels {
+		if len(bands) == 0 || bands[len(bands)-1] != ch.Band {
+			bands = append(bands, ch.Band)
+		}
+	}
+	return bands
+}
+
+// ChannelAllowed maps a frequency, e.g. ScanResult.Frequency(), to its
+// channel, and tells whether the device may use the channel.  Channels
+// are enabled according to the regulatory domain in effect when the
+// capabilities were queried; NoIR and DFS tell how they may be used.
+func (c *Capabilities) ChannelAllowed(freq int) (SupportedChannel, bool) {
+	for _, ch := range c.Channels {
+		if ch.Freq == freq {
+			return ch, true
+		}
+	}
+
+	ch, _ := ChannelFromFrequency(freq)
+	return SupportedChannel{Channel: ch}, false
+}
+
+// SupportsEAP returns true if the EAP method is supported.
+func (c *Capabilities) SupportsEAP(method EAPMethod) bool {
+	for _, m := range c.EAP {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (uc *unixgram) Capabilities() (*Capabilities, error) {
+	caps := &Capabilities{}
+	var channels string
+	for _, name := range capabilityNames {
+		resp, err := uc.cmd("GET_CAPABILITY " + name)
+		if errors.Is(err, ErrFail) || errors.Is(err, ErrUnknownCommand) {
+			caps.Unknown = append(caps.Unknown, name)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		v := strings.TrimSuffix(string(resp), "\n")
+		switch name {
+		case "eap":
+			for _, m := range strings.Fields(v) {
+				caps.EAP = append(caps.EAP, EAPMethod(m))
+			}
+		case "pairwise":
+			caps.Pairwise = parseCipherNames(v)
+		case "group":
+			caps.Group = parseCipherNames(v)
+		case "key_mgmt":
+			caps.KeyMgmt = parseKeyMgmtNames(v)
+		case "proto":
+			caps.Proto = strings.Fields(v)
+		case "auth_alg":
+			caps.AuthAlg = strings.Fields(v)
+		case "modes":
+			caps.Modes = strings.Fields(v)
+		case "channels":
+			channels = v
+		case "freq":
+			caps.Channels = parseCapabilityFreq(v)
+		case "tdls":
+			if v != "UNSUPPORTED" {
+				caps.TDLS = v
+			}
+		case "erp":
+			caps.ERP = v == "ERP"
+		case "fips":
+			caps.FIPS = v == "FIPS"
+		case "acs":
+			caps.ACS = v == "ACS"
+		case "pmf":
+			caps.PMF = v == "PMF"
+		case "sae":
+			for _, f := range strings.Fields(v) {
+				caps.SAEH2E = caps.SAEH2E || f == "H2E"
+				caps.SAEPK = caps.SAEPK || f == "PK"
+			}
+		case "dpp":
+			if strings.HasPrefix(v, "DPP=") {
+				caps.DPPVersion, _ = strconv.Atoi(v[4:])
+			}
+		}
+	}
+
+	// Older versions only list the channel numbers.
+	if !caps.Known("freq") {
+		caps.Channels = parseCapabilityChannels(channels)
+	}
+
+	uc.capsLock.Lock()
+	uc.caps = caps
+	uc.capsLock.Unlock()
+
+	return caps, nil
+}
+
+// parseCipherNames parses a list of cipher names, ignoring unknown ones.
+func parseCipherNames(s string) Cipher {
+	var c Cipher
+	for _, name := range strings.Fields(s) {
+		v, _ := lookupCipher(name)
+		c |= v
+	}
+	return c
+}
+
+// parseKeyMgmtNames parses a list of key management names, ignoring
+// unknown ones.
+func parseKeyMgmtNames(s string) KeyMgmt {
+	var k KeyMgmt
+	for _, name := range strings.Fields(s) {
+		v, _ := ParseKeyMgmt(name)
+		k |= v
+	}
+	return k
+}
+
+// parseCapabilityFreq parses the reply to GET_CAPABILITY freq:
+//
+//	Mode[G] Channels:
+//	 1 = 2412 MHz
+//	 ...
+//	Mode[A] Channels:
+//	 52 = 5260 MHz (NO_IR) (DFS)
+//
+// 2.4 GHz channels are listed for both the B and G modes.
+func parseCapabilityFreq(v string) []SupportedChannel {
+	seen := map[int]bool{}
+	var res []SupportedChannel
+
+	s := bufio.NewScanner(strings.NewReader(v))
+	for s.Scan() {
+		var number, freq int
+		if _, err := fmt.Sscanf(s.Text(), " %d = %d MHz", &number, &freq); err != nil || seen[freq] {
+			continue
+		}
+		ch, ok := ChannelFromFrequency(freq)
+		if !ok {
+			continue
+		}
+		seen[freq] = true
+		res = append(res, SupportedChannel{
+			Channel: ch,
+			NoIR:    strings.Contains(s.Text(), "(NO_IR)"),
+			DFS:     strings.Contains(s.Text(), "(DFS)"),
+		})
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Freq < res[j].Freq })
+	return res
+}
+
+// parseCapabilityChannels parses the reply to GET_CAPABILITY channels, e.g.
+// "Mode[G] Channels: 1 2 3 ...", one line per mode.
+func parseCapabilityChannels(v string) []SupportedChannel {
+	seen := map[int]bool{}
+	var res []SupportedChannel
+
+	for _, ln := range strings.Split(v, "\n") {
+		i := strings.Index(ln, "Channels:")
+		if !strings.HasPrefix(ln, "Mode[") || i == -1 {
+			continue
+		}
+
+		var band Band
+		switch ln[len("Mode["):strings.IndexByte(ln, ']')] {
+		case "B", "G":
+			band = Band2GHz
+		case "A":
+			band = Band5GHz
+		case "AD":
+			band = Band60GHz
+		default:
+			continue
+		}
+
+		for _, f := range strings.Fields(ln[i+len("Channels:"):]) {
+			n, err := strconv.Atoi(f)
+			if err != nil {
+				continue
+			}
+			ch, err := NewChannel(band, n)
+			if err != nil || seen[ch.Freq] {
+				continue
+			}
+			seen[ch.Freq] = true
+			res = append(res, SupportedChannel{Channel: ch})
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Freq < res[j].Freq })
+	return res
+}
+
+// CheckCapabilities checks that the device supports the config, returning
+// an error wrapping ErrNotSupported if it doesn't.  Fields wpa_supplicant
+// didn't report aren't checked.
+func (c NetworkConfig) CheckCapabilities(caps *Capabilities) error {
+	keyMgmt := c.keyMgmt()
+	if missing := keyMgmt & checkedKeyMgmt &^ caps.KeyMgmt; caps.Known("key_mgmt") && missing != 0 {
+		return fmt.Errorf("%w: key management %s", ErrNotSupported, missing)
+	}
+	if missing := c.Pairwise &^ caps.Pairwise; caps.Known("pairwise") && missing != 0 {
+		return fmt.Errorf("%w: pairwise cipher %s", ErrNotSupported, missing)
+	}
+	if missing := c.Group &^ caps.Group; caps.Known("group") && missing != 0 {
+		return fmt.Errorf("%w: group cipher %s", ErrNotSupported, missing)
+	}
+
+	// SAE and OWE require management frame protection.
+	pmf := c.PMF == PMFRequired || keyMgmt&^(saeKeyMgmt|OWE) == 0 && keyMgmt&(saeKeyMgmt|OWE) != 0
+	if pmf && caps.Known("pmf") && !caps.PMF {
+		return fmt.Errorf("%w: management frame protection", ErrNotSupported)
+	}
+
+	if c.EAP != nil && caps.Known("eap") && !caps.SupportsEAP(c.EAP.Method) {
+		return fmt.Errorf("%w: EAP method %s", ErrNotSupported, c.EAP.Method)
+	}
+
+	return nil
+}
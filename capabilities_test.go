@@ -0,0 +1,93 @@
+package wpasupplicant_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-laeo/wpasupplicant"
+)
+
+func TestCapabilities(t *testing.T) {
+	srv, conn := connect(t)
+
+	caps, err := conn.Capabilities()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if caps.KeyMgmt&wpasupplicant.SAE == 0 || caps.KeyMgmt&wpasupplicant.OWE == 0 {
+		t.Errorf("key management %s", caps.KeyMgmt)
+	}
+	if caps.Pairwise&wpasupplicant.CCMP == 0 || caps.Group&wpasupplicant.TKIP == 0 {
+		t.Errorf("ciphers %s, %s", caps.Pairwise, caps.Group)
+	}
+	if !caps.SupportsEAP("AKA'") || !caps.PMF || !caps.SAEH2E || caps.SAEPK || caps.DPPVersion != 2 || caps.TDLS != "EXTERNAL" {
+		t.Errorf("got %+v", caps)
+	}
+	if caps.Known("fips") || caps.FIPS {
+		t.Error("fips should be unknown")
+	}
+
+	if len(caps.Channels) != 5 {
+		t.Fatalf("got channels %+v", caps.Channels)
+	}
+	if ch := caps.Channels[4]; ch.Number != 52 || !ch.NoIR || !ch.DFS {
+		t.Errorf("got %+v", ch)
+	}
+	if bands := caps.Bands(); len(bands) != 2 || bands[0] != wpasupplicant.Band2GHz || bands[1] != wpasupplicant.Band5GHz {
+		t.Errorf("got bands %v", bands)
+	}
+
+	// Older versions only report channel numbers.
+	srv.SetCapability("freq", "")
+	caps, err = conn.Capabilities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(caps.Channels) != 5 || caps.Channels[0].Freq != 2412 || caps.Channels[4].Freq != 5260 || caps.Channels[4].DFS {
+		t.Errorf("got channels %+v", caps.Channels)
+	}
+}
+
+func TestCheckCapabilities(t *testing.T) {
+	srv, conn := connect(t)
+	srv.SetCapability("key_mgmt", "NONE IEEE8021X WPA-EAP WPA-PSK")
+	srv.SetCapability("pairwise", "CCMP TKIP NONE")
+	srv.SetCapability("pmf", "")
+
+	sae := wpasupplicant.NetworkConfig{SSID: "home", SAEPassword: "correct horse"}
+
+	// Configs aren't checked until the capabilities are known.
+	if _, err := conn.CreateNetwork(sae); err != nil {
+		t.Fatal(err)
+	}
+
+	caps, err := conn.Capabilities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.CreateNetwork(sae); !errors.Is(err, wpasupplicant.ErrNotSupported) {
+		t.Errorf("SAE: got %v", err)
+	}
+
+	for _, c := range []wpasupplicant.NetworkConfig{
+		{SSID: "psk", PSK: "12345678", Pairwise: wpasupplicant.CCMP},
+		// PMF is unknown, so it isn't checked.
+		{SSID: "pmf", PSK: "12345678", PMF: wpasupplicant.PMFRequired},
+		{SSID: "eap", EAP: &wpasupplicant.EAPConfig{Method: "TLS", Identity: "user", CACert: "/ca.pem", ClientCert: "/client.pem", PrivateKey: "/key.pem"}},
+	} {
+		if err := c.CheckCapabilities(caps); err != nil {
+			t.Errorf("%s: %v", c.SSID, err)
+		}
+	}
+
+	for _, c := range []wpasupplicant.NetworkConfig{
+		{SSID: "owe", KeyMgmt: wpasupplicant.OWE},
+		{SSID: "gcmp", PSK: "12345678", Pairwise: wpasupplicant.GCMP_256},
+		{SSID: "fast", EAP: &wpasupplicant.EAPConfig{Method: "FAST", Identity: "user", Password: "secret"}},
+	} {
+		if err := c.CheckCapabilities(caps); !errors.Is(err, wpasupplicant.ErrNotSupported) {
+			t.Errorf("%s: got %v", c.SSID, err)
+		}
+	}
+}
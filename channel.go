@@ -0,0 +1,251 @@
+package wpasupplicant
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Band is a frequency band.
+type Band int
+
+const (
+	BandUnknown Band = iota
+	Band2GHz         // 2.4 GHz
+	Band5GHz
+	Band6GHz
+	Band60GHz
+)
+
+func (b Band) String() string {
+	switch b {
+	case Band2GHz:
+		return "2.4 GHz"
+	case Band5GHz:
+		return "5 GHz"
+	case Band6GHz:
+		return "6 GHz"
+	case Band60GHz:
+		return "60 GHz"
+	}
+	return "band " + strconv.Itoa(int(b))
+}
+
+// ChannelFrequency returns the center frequency in MHz of a 20 MHz channel
+// (2.16 GHz for 60 GHz) in the given band.
+func ChannelFrequency(band Band, channel int) (int, error) {
+	switch {
+	case band == Band2GHz && channel >= 1 && channel <= 13:
+		return 2407 + 5*channel, nil
+	case band == Band2GHz && channel == 14:
+		return 2484, nil
+	case band == Band5GHz && channel >= 32 && channel <= 177:
+		return 5000 + 5*channel, nil
+	case band == Band6GHz && channel == 2:
+		return 5935, nil
+	case band == Band6GHz && channel >= 1 && channel <= 233 && channel%4 == 1:
+		return 5950 + 5*channel, nil
+	case band == Band60GHz && channel >= 1 && channel <= 6:
+		return 56160 + 2160*channel, nil
+	}
+	return 0, fmt.Errorf("invalid channel %d in the %s band", channel, band)
+}
+
+// FrequencyChannel returns the band and channel of a center frequency in
+// MHz, or BandUnknown if it isn't a Wi-Fi channel.
+func FrequencyChannel(freq int) (Band, int) {
+	switch {
+	case freq == 2484:
+		return Band2GHz, 14
+	case freq >= 2412 && freq <= 2472 && (freq-2407)%5 == 0:
+		return Band2GHz, (freq - 2407) / 5
+	case freq == 5935:
+		return Band6GHz, 2
+	case freq >= 5160 && freq <= 5885 && freq%5 == 0:
+		return Band5GHz, (freq - 5000) / 5
+	case freq >= 5955 && freq <= 7115 && (freq-5950)%20 == 5:
+		return Band6GHz, (freq - 5950) / 5
+	case freq >= 58320 && freq <= 69120 && (freq-56160)%2160 == 0:
+		return Band60GHz, (freq - 56160) / 2160
+	}
+	return BandUnknown, 0
+}
+
+// ChannelWidth is the bandwidth of a channel, in MHz.
+type ChannelWidth int
+
+const (
+	WidthUnknown ChannelWidth = 0
+	Width20      ChannelWidth = 20
+	Width40      ChannelWidth = 40
+	Width80      ChannelWidth = 80
+	Width160     ChannelWidth = 160
+	Width320     ChannelWidth = 320
+	Width2160    ChannelWidth = 2160
+)
+
+func (w ChannelWidth) String() string {
+	if w == WidthUnknown {
+		return "unknown width"
+	}
+	return strconv.Itoa(int(w)) + " MHz"
+}
+
+// Channel is a Wi-Fi channel.  Number and Freq are those of the primary 20
+// MHz channel (2.16 GHz for 60 GHz); Width is the width of the whole
+// channel, if known.
+type Channel struct {
+	Band   Band         `json:"band"`
+	Number int          `json:"number"`
+	Freq   int          `json:"freq"`
+	Width  ChannelWidth `json:"width,omitempty"`
+}
+
+// NewChannel returns the channel with the given number in a band.
+func NewChannel(band Band, number int) (Channel, error) {
+	freq, err := ChannelFrequency(band, number)
+	if err != nil {
+		return Channel{}, err
+	}
+	return Channel{Band: band, Number: number, Freq: freq}, nil
+}
+
+// ChannelFromFrequency returns the channel with the given center frequency
+// in MHz, or false if it isn't a Wi-Fi channel.
+func ChannelFromFrequency(freq int) (Channel, bool) {
+	band, number := FrequencyChannel(freq)
+	if band == BandUnknown {
+		return Channel{}, false
+	}
+	return Channel{Band: band, Number: number, Freq: freq}, true
+}
+
+// PSC returns true for the 6 GHz preferred scanning channels (5, 21, 37,
+// ...), on which 6 GHz access points are discoverable.
+func (c Channel) PSC() bool {
+	return c.Band == Band6GHz && c.Number%16 == 5
+}
+
+func (c Channel) String() string {
+	s := "channel " + strconv.Itoa(c.Number) + " (" + c.Band.String()
+	if c.Width != WidthUnknown {
+		s += ", " + c.Width.String()
+	}
+	return s + ")"
+}
+
+// Information elements used to determine the channel width.
+const (
+	ieHTOperation  = 61
+	ieVHTOperation = 192
+	ieExtension    = 255
+	ieExtHEOp      = 36
+	ieExtEHTOp     = 106
+)
+
+// channelWidth determines the width of a channel from the information
+// elements of a BSS on it.
+func channelWidth(band Band, ies []byte) ChannelWidth {
+	if band == Band60GHz {
+		return Width2160
+	}
+
+	width := Width20
+	for len(ies) >= 2 {
+		id, n := ies[0], int(ies[1])
+		if len(ies) < 2+n {
+			break
+		}
+		data := ies[2 : 2+n]
+		ies = ies[2+n:]
+
+		var w ChannelWidth
+		switch {
+		case id == ieHTOperation && len(data) >= 2:
+			// Secondary channel offset, and any width allowed.
+			if data[1]&0x03 != 0 && data[1]&0x04 != 0 {
+				w = Width40
+			}
+		case id == ieVHTOperation && len(data) >= 3:
+			w = vhtWidth(data[0], data[1], data[2])
+		case id == ieExtension && len(data) >= 1 && data[0] == ieExtHEOp:
+			w = heWidth(data[1:])
+		case id == ieExtension && len(data) >= 1 && data[0] == ieExtEHTOp:
+			w = ehtWidth(data[1:])
+		}
+		if w > width {
+			width = w
+		}
+	}
+	return width
+}
+
+// vhtWidth decodes the channel width of a VHT Operation element.
+func vhtWidth(width, ccfs0, ccfs1 byte) ChannelWidth {
+	switch width {
+	case 1:
+		// 80 MHz, or 160 MHz (80+80 if not contiguous) if the second
+		// segment is set.
+		if ccfs1 == 0 {
+			return Width80
+		}
+		return Width160
+	case 2, 3:
+		// Deprecated 160 and 80+80 MHz.
+		return Width160
+	}
+	return WidthUnknown
+}
+
+// heWidth decodes the channel width of the 6 GHz Operation Information of
+// an HE Operation element.
+func heWidth(data []byte) ChannelWidth {
+	// HE Operation Parameters (3), BSS Color (1), Basic HE-MCS (2).
+	if len(data) < 6 {
+		return WidthUnknown
+	}
+	params := uint32(data[0]) | uint32(data[1])<<8 | uint32(data[2])<<16
+	off := 6
+	if params&(1<<14) != 0 {
+		off += 3 // VHT Operation Information
+	}
+	if params&(1<<15) != 0 {
+		off++ // Max Co-Hosted BSSID Indicator
+	}
+	if params&(1<<17) == 0 || len(data) < off+5 {
+		return WidthUnknown
+	}
+
+	// Primary Channel, Control, CCFS0, CCFS1, Minimum Rate.
+	switch data[off+1] & 0x03 {
+	case 0:
+		return Width20
+	case 1:
+		return Width40
+	case 2:
+		return Width80
+	}
+	return Width160
+}
+
+// ehtWidth decodes the channel width of an EHT Operation element.
+func ehtWidth(data []byte) ChannelWidth {
+	// EHT Operation Parameters (1), Basic EHT-MCS (4), then the EHT
+	// Operation Information if present.
+	if len(data) < 6 || data[0]&0x01 == 0 {
+		return WidthUnknown
+	}
+
+	switch data[5] & 0x07 {
+	case 0:
+		return Width20
+	case 1:
+		return Width40
+	case 2:
+		return Width80
+	case 3:
+		return Width160
+	case 4:
+		return Width320
+	}
+	return WidthUnknown
+}
@@ -0,0 +1,87 @@
+package wpasupplicant
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestChannelFrequency(t *testing.T) {
+	tests := []struct {
+		band    Band
+		channel int
+		freq    int
+	}{
+		{Band2GHz, 1, 2412},
+		{Band2GHz, 13, 2472},
+		{Band2GHz, 14, 2484},
+		{Band5GHz, 36, 5180},
+		{Band5GHz, 165, 5825},
+		{Band6GHz, 1, 5955},
+		{Band6GHz, 2, 5935},
+		{Band6GHz, 37, 6135},
+		{Band60GHz, 2, 60480},
+	}
+
+	for _, test := range tests {
+		freq, err := ChannelFrequency(test.band, test.channel)
+		if err != nil || freq != test.freq {
+			t.Errorf("%s channel %d: got %d (%v), expect %d", test.band, test.channel, freq, err, test.freq)
+		}
+		if band, channel := FrequencyChannel(test.freq); band != test.band || channel != test.channel {
+			t.Errorf("%d MHz: got %s channel %d", test.freq, band, channel)
+		}
+	}
+
+	if _, err := ChannelFrequency(Band6GHz, 3); err == nil {
+		t.Error("expected an error for 6 GHz channel 3")
+	}
+	if band, _ := FrequencyChannel(2400); band != BandUnknown {
+		t.Errorf("2400 MHz: got %s", band)
+	}
+}
+
+func TestChannelWidth(t *testing.T) {
+	tests := []struct {
+		name  string
+		band  Band
+		ies   string
+		width ChannelWidth
+	}{
+		{"legacy", Band2GHz, "000468656c6c6f", Width20},
+		{"HT20", Band2GHz, "3d1606000000000000000000000000000000000000000000", Width20},
+		{"HT40", Band5GHz, "3d1624050000000000000000000000000000000000000000", Width40},
+		{"VHT80", Band5GHz, "3d1624050000000000000000000000000000000000000000c005012a000000", Width80},
+		{"VHT160", Band5GHz, "c0050132320000", Width160},
+		{"HE 6 GHz", Band6GHz, "ff0c24000002000000250300000000", Width160},
+		{"EHT320", Band6GHz, "ff096a0100000000041f00", Width320},
+		{"truncated", Band5GHz, "c0050132", Width20},
+		{"60 GHz", Band60GHz, "", Width2160},
+	}
+
+	for _, test := range tests {
+		ies, err := hex.DecodeString(test.ies)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if width := channelWidth(test.band, ies); width != test.width {
+			t.Errorf("%s: got %s, expect %s", test.name, width, test.width)
+		}
+	}
+}
+
+func TestChannelPSC(t *testing.T) {
+	for _, test := range []struct {
+		freq int
+		psc  bool
+	}{
+		{5975, true},  // channel 5
+		{5955, false}, // channel 1
+		{6295, true},  // channel 69
+		{5180, false}, // 5 GHz channel 36
+	} {
+		ch, ok := ChannelFromFrequency(test.freq)
+		if !ok || ch.PSC() != test.psc {
+			t.Errorf("%d MHz: got %v, %v", test.freq, ch, ok)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Command wpad-gateway serves the control interface of a wpa_supplicant
+// network interface over HTTP.  See package httpapi for the endpoints.
+//
+// Usage:
+//
+//	wpad-gateway -i wlan0 -listen unix:/run/wpad-gateway.sock
+//	wpad-gateway -i wlan0 -listen 127.0.0.1:8080 -token secret
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/go-laeo/wpasupplicant"
+	"github.com/go-laeo/wpasupplicant/httpapi"
+)
+
+func main() {
+	iface := flag.String("i", "wlan0", "network interface to control")
+	ctrlPath := flag.String("ctrl", "/run/wpa_supplicant", "directory containing the wpa_supplicant control sockets")
+	listen := flag.String("listen", "127.0.0.1:8080", "address to listen on, either host:port or unix:/path")
+	token := flag.String("token", "", "require this bearer token on every request")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	conn, err := wpasupplicant.ConnectPath(ctx, *ctrlPath, *iface)
+	if err != nil {
+		log.Fatalf("connecting to wpa_supplicant: %s", err)
+	}
+	defer conn.Close()
+
+	var options []httpapi.Option
+	if *token != "" {
+		options = append(options, httpapi.WithMiddleware(httpapi.BearerAuth(*token)))
+	}
+
+	l, err := listener(*listen)
+	if err != nil {
+		log.Fatalf("listening on %s: %s", *listen, err)
+	}
+
+	srv := &http.Server{Handler: httpapi.NewHandler(conn, options...)}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	if err := srv.Serve(l); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
+
+// listener opens a TCP listener, or a unix socket listener if addr starts
+// with "unix:".  A stale unix socket is removed first.
+func listener(addr string) (net.Listener, error) {
+	if strings.HasPrefix(addr, "unix:") {
+		path := strings.TrimPrefix(addr, "unix:")
+		os.Remove(path)
+		return net.Listen("unix", path)
+	}
+	return net.Listen("tcp", addr)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListener(t *testing.T) {
+	l, err := listener("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if network := l.Addr().Network(); network != "tcp" {
+		t.Errorf("got a %s listener, expect tcp", network)
+	}
+	l.Close()
+
+	// A stale socket left behind by a previous run is replaced.
+	path := filepath.Join(t.TempDir(), "gateway.sock")
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err = listener("unix:" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if addr := l.Addr(); addr.Network() != "unix" || addr.String() != path {
+		t.Errorf("got %s %s, expect unix %s", addr.Network(), addr, path)
+	}
+}
@@ -1,5 +1,7 @@
 package wpasupplicant
 
+import "encoding/json"
+
 // ConfiguredNetwork is a configured network (from LIST_NETWORKS)
 type ConfiguredNetwork interface {
 	NetworkID() string
@@ -19,3 +21,52 @@ func (r *configuredNetwork) NetworkID() string { return r.networkID }
 func (r *configuredNetwork) BSSID() string     { return r.bssid }
 func (r *configuredNetwork) SSID() string      { return r.ssid }
 func (r *configuredNetwork) Flags() []string   { return r.flags }
+
+// configuredNetworkJSON is the JSON representation of a configuredNetwork.
+type configuredNetworkJSON struct {
+	NetworkID string   `json:"network_id"`
+	SSID      string   `json:"ssid"`
+	BSSID     string   `json:"bssid"`
+	Flags     []string `json:"flags"`
+}
+
+// MarshalJSON encodes the network as an object with the fields network_id,
+// ssid, bssid (which may be "any") and flags.
+func (r *configuredNetwork) MarshalJSON() ([]byte, error) {
+	v := configuredNetworkJSON{
+		NetworkID: r.networkID,
+		SSID:      r.ssid,
+		BSSID:     r.bssid,
+		Flags:     r.flags,
+	}
+	if v.Flags == nil {
+		v.Flags = []string{}
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes the format written by MarshalJSON.
+func (r *configuredNetwork) UnmarshalJSON(data []byte) error {
+	var v configuredNetworkJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*r = configuredNetwork{
+		networkID: v.NetworkID,
+		ssid:      v.SSID,
+		bssid:     v.BSSID,
+		flags:     v.Flags,
+	}
+	return nil
+}
+
+// UnmarshalConfiguredNetwork decodes a ConfiguredNetwork previously encoded
+// with json.Marshal.
+func UnmarshalConfiguredNetwork(data []byte) (ConfiguredNetwork, error) {
+	r := &configuredNetwork{}
+	if err := r.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
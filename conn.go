@@ -1,5 +1,10 @@
 package wpasupplicant
 
+import (
+	"context"
+	"net"
+)
+
 // Conn is a connection to wpa_supplicant over one of its communication
 // channels.
 type Conn interface {
@@ -18,10 +23,16 @@ type Conn interface {
 	// configuration failed.
 	// Value's type must one of int, string and []byte. The int type always shown
 	// without double quotes. The string type always shown with double quotes except
-	// the variable name is key_mgmt. The []byte type may only uses for ssid, maybe
+	// for keyword variables such as key_mgmt, proto, pairwise, group, eap and bssid,
+	// and "hash:" passwords. The []byte type may only uses for ssid and raw psk, maybe
 	// useful when it contains non-ascii encoded chars.
 	SetNetwork(networkID int, field string, value interface{}) error
 
+	// CreateNetwork adds a network configured from a NetworkConfig, which
+	// is validated first, and checked against the capabilities if
+	// Capabilities was called.  Returns the network ID.
+	CreateNetwork(NetworkConfig) (int, error)
+
 	// EnableNetwork enables a network. Returns error if the command fails.
 	EnableNetwork(int) error
 
@@ -62,6 +73,48 @@ type Conn interface {
 	// Status returns current wpa_supplicant status
 	Status() (StatusResult, error)
 
+	// Disconnect disconnects and stops connecting until Reconnect or
+	// SelectNetwork.
+	Disconnect() error
+
+	// Reauthenticate forces EAPOL reauthentication.
+	Reauthenticate() error
+
+	// Preauth starts RSN pre-authentication with an access point.
+	Preauth(bssid net.HardwareAddr) error
+
+	// Logon and Logoff simulate the user logging on and off, which starts
+	// and stops IEEE 802.1X authentication.
+	Logon() error
+	Logoff() error
+
+	// SetAutoconnect enables or disables connecting automatically to the
+	// enabled networks (STA_AUTOCONNECT).
+	SetAutoconnect(enabled bool) error
+
+	// SetManualMode disables automatic connection while manual is true,
+	// and enables it again when the connection is closed.  See the
+	// ManualMode option.
+	SetManualMode(manual bool) error
+
+	// AbortScan aborts the ongoing scan.  It fails if there is none.
+	AbortScan() error
+
+	// Flush resets wpa_supplicant's state: removes all networks and
+	// credentials and clears the BSS table and ignore list.
+	Flush() error
+
+	// Suspend and Resume tell wpa_supplicant that the system is going to
+	// sleep and woke up.
+	Suspend() error
+	Resume() error
+
+	// Terminate makes wpa_supplicant exit.
+	Terminate() error
+
+	// Driver sends a driver specific command, returning its reply.
+	Driver(cmd string) (string, error)
+
 	// Scan triggers a new scan. Returns error if the wpa_supplicant does not
 	// return OK.
 	Scan() error
@@ -69,7 +122,148 @@ type Conn interface {
 	// ScanResult returns the latest scanning results.  It returns a slice
 	// of scanned BSSs, and/or a slice of errors representing problems
 	// communicating with wpa_supplicant or parsing its output.
-	ScanResults() ([]ScanResult, []error)
+	ScanResults() (ScanResults, []error)
+
+	// BSS returns the entry of the BSS table for an access point, with
+	// more details than ScanResults, such as the channel width.  Returns
+	// ErrUnknownBSS if wpa_supplicant doesn't know the access point.
+	BSS(bssid net.HardwareAddr) (*BSS, error)
+
+	// WPSPushButton starts WPS push button configuration with the AP
+	// bssid, or any AP in push button mode if bssid is nil.  It waits for
+	// WPS to complete and for wpa_supplicant to connect, and returns the
+	// ID of the network wpa_supplicant created.  Failures are reported as
+	// ErrWPSTimeout, ErrWPSOverlap or *WPSError.  WPS is cancelled if ctx
+	// is done first.
+	WPSPushButton(ctx context.Context, bssid net.HardwareAddr) (int, error)
+
+	// WPSPin is like WPSPushButton, using PIN configuration with the given
+	// PIN, which the user enters on the AP.  See GenerateWPSPin.
+	WPSPin(ctx context.Context, bssid net.HardwareAddr, pin string) (int, error)
+
+	// WPSCancel cancels an ongoing WPS operation.
+	WPSCancel() error
+
+	// CheckWPSPin asks wpa_supplicant to validate a PIN.  It returns the
+	// PIN in the normalized form wpa_supplicant uses, without separators,
+	// or ErrWPSPinChecksum if the checksum digit is wrong.  See
+	// ValidWPSPin for checking PINs without wpa_supplicant.
+	CheckWPSPin(pin string) (string, error)
+
+	// AddCred creates an empty Hotspot 2.0 credential.  Returns the
+	// credential ID.
+	AddCred() (int, error)
+
+	// SetCred sets a credential variable, with the same value types and
+	// quoting as SetNetwork.  eap and the roaming consortium variables
+	// are not quoted.
+	SetCred(credID int, variable string, value interface{}) error
+
+	// RemoveCred removes a credential.
+	RemoveCred(credID int) error
+
+	// ListCreds returns the configured credentials.
+	ListCreds() ([]Cred, error)
+
+	// InterworkingSelect fetches ANQP information from the access points
+	// and matches it against the credentials, reporting the matches with
+	// EventInterworkingAP.  If auto is true, the best match is connected
+	// to.
+	InterworkingSelect(auto bool) error
+
+	// InterworkingConnect connects to an access point using a matching
+	// credential.
+	InterworkingConnect(bssid net.HardwareAddr) error
+
+	// ANQPGet queries ANQP elements (see the ANQP constants) from an
+	// access point.  EventANQPQueryDone is sent when the query completes.
+	ANQPGet(bssid net.HardwareAddr, infoIDs []int) error
+
+	// HS20IconRequest requests an icon from an access point.
+	HS20IconRequest(bssid net.HardwareAddr, filename string) error
+
+	// SendCredential answers a credential request (see EventCtrlReq) for
+	// a network.  The PromptCredentials option does this automatically.
+	SendCredential(field string, networkID int, value string) error
+
+	// Roam roams to another BSS of the current network.  The roam
+	// completes with an EventConnected event.
+	Roam(bssid net.HardwareAddr) error
+
+	// SignalPoll returns the signal level and other properties of the
+	// current link.
+	SignalPoll() (*SignalInfo, error)
+
+	// NeighborReportRequest asks the current access point for its
+	// neighbors (802.11k).  Each one is reported with an
+	// EventNeighborReport event: see ParseNeighborReport.
+	NeighborReportRequest(config NeighborReportConfig) error
+
+	// WNMBSSQuery sends a BSS transition management query (802.11v) with
+	// one of the BTMReason constants, and the scanned candidates if
+	// listCandidates is true.  The access point answers with a
+	// transition request: see ParseBSSTMEvent.
+	WNMBSSQuery(reason int, listCandidates bool) error
+
+	// WNMSleep enters WNM sleep mode for interval beacon intervals (the
+	// default if 0), or exits it.
+	WNMSleep(enter bool, interval int) error
+
+	// FTDS roams to another BSS of the current network with fast
+	// transition (802.11r) over the distribution system.
+	FTDS(bssid net.HardwareAddr) error
+
+	// IgnoreBSSID adds an access point to the BSSID ignore list, so that
+	// wpa_supplicant doesn't connect to it.  Older versions of
+	// wpa_supplicant, which call the list the blacklist, are detected
+	// automatically.  See IgnorePolicy.
+	IgnoreBSSID(bssid net.HardwareAddr) error
+
+	// IgnoredBSSIDs returns the BSSID ignore list, which includes the
+	// access points wpa_supplicant ignores on its own after failures.
+	IgnoredBSSIDs() ([]net.HardwareAddr, error)
+
+	// ClearIgnoredBSSIDs empties the BSSID ignore list.
+	ClearIgnoredBSSIDs() error
+
+	// StartAccessPoint creates and selects an AP mode network and waits
+	// for wpa_supplicant to enable it.  Use ParseStationEvent to follow
+	// stations connecting to it.
+	StartAccessPoint(ctx context.Context, config APConfig) error
+
+	// StopAccessPoint removes the network created by StartAccessPoint and
+	// restores the previously selected and enabled client networks.
+	StopAccessPoint() error
+
+	// SetCountry sets the regulatory domain to an ISO 3166-1 alpha-2
+	// country code, or WorldRegdom.  The driver confirms the change with
+	// EventRegdomChange.
+	SetCountry(code string) error
+
+	// Country returns the country code of the regulatory domain.
+	Country() (string, error)
+
+	// ChannelAllowed maps a frequency, e.g. ScanResult.Frequency(), to
+	// its channel, and tells whether the device may use the channel under
+	// the regulatory domain currently in effect.
+	ChannelAllowed(freq int) (SupportedChannel, bool, error)
+
+	// Capabilities queries what the device and wpa_supplicant support,
+	// such as the key management types and channels.
+	Capabilities() (*Capabilities, error)
+
+	// Settings returns the global settings of wpa_supplicant.
+	Settings() Settings
+
+	// P2P returns the Wi-Fi Direct API of the connection.
+	P2P() P2P
 
 	EventQueue() chan WPAEvent
+
+	// Subscribe returns a channel which receives a copy of every event,
+	// and a function which cancels the subscription and closes the
+	// channel.  Unlike EventQueue, any number of subscribers may be active
+	// at once.  Events are dropped for subscribers which don't keep up.
+	// The channel is also closed when the connection is.
+	Subscribe() (<-chan WPAEvent, func())
 }
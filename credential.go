@@ -0,0 +1,183 @@
+package wpasupplicant
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventCtrlReq is the event name of CTRL-REQ-<field>-<id>:<text> messages,
+// in which wpa_supplicant asks for a credential it doesn't have.  The
+// arguments are "field", "id" and "text"; see ParseCredentialRequest.
+const EventCtrlReq = "CTRL-REQ"
+
+// Credential fields wpa_supplicant may ask for.
+const (
+	CredentialIdentity      = "IDENTITY"
+	CredentialPassword      = "PASSWORD"
+	CredentialNewPassword   = "NEW_PASSWORD"
+	CredentialPIN           = "PIN"
+	CredentialOTP           = "OTP"
+	CredentialPassphrase    = "PASSPHRASE"
+	CredentialSIM           = "SIM"
+	CredentialPSKPassphrase = "PSK_PASSPHRASE"
+	CredentialExtCertCheck  = "EXT_CERT_CHECK"
+)
+
+// CredentialRequest is a request for a credential.
+type CredentialRequest struct {
+	// Field is the credential requested, one of the Credential
+	// constants.
+	Field string
+
+	// NetworkID is the network which needs the credential.
+	NetworkID int
+
+	// Text is the human readable prompt, e.g. "Password needed for SSID
+	// corp".
+	Text string
+
+	// Interface is the interface concerned, on a global control
+	// interface.
+	Interface string
+}
+
+// ParseCredentialRequest returns the CredentialRequest for e, or false if
+// e isn't a CTRL-REQ event.
+func ParseCredentialRequest(e WPAEvent) (*CredentialRequest, bool) {
+	if e.Event != EventCtrlReq {
+		return nil, false
+	}
+
+	id, err := strconv.Atoi(e.Arguments["id"])
+	if err != nil {
+		return nil, false
+	}
+
+	return &CredentialRequest{
+		Field:     e.Arguments["field"],
+		NetworkID: id,
+		Text:      e.Arguments["text"],
+		Interface: e.Interface,
+	}, true
+}
+
+// parseCtrlReq parses CTRL-REQ-<field>-<id>:<text>.  The field may contain
+// underscores but not dashes.
+func parseCtrlReq(data string) (WPAEvent, bool) {
+	rest := strings.TrimPrefix(data, "CTRL-REQ-")
+	colon := strings.IndexByte(rest, ':')
+	if colon == -1 {
+		return WPAEvent{}, false
+	}
+	dash := strings.LastIndexByte(rest[:colon], '-')
+	if dash == -1 {
+		return WPAEvent{}, false
+	}
+
+	return WPAEvent{
+		Event: EventCtrlReq,
+		Arguments: map[string]string{
+			"field": rest[:dash],
+			"id":    rest[dash+1 : colon],
+			"text":  rest[colon+1:],
+		},
+		Line: data,
+	}, true
+}
+
+// CredentialPrompter supplies credentials wpa_supplicant asks for, e.g. by
+// showing a dialog.
+type CredentialPrompter interface {
+	// PromptCredential returns the value of the requested credential.
+	// ctx is cancelled when the prompt times out, when wpa_supplicant
+	// asks for the same credential again, or when the connection is
+	// closed.  If an error is returned, no response is sent.
+	PromptCredential(ctx context.Context, req CredentialRequest) (string, error)
+}
+
+// CredentialPrompterFunc is a function implementing CredentialPrompter.
+type CredentialPrompterFunc func(ctx context.Context, req CredentialRequest) (string, error)
+
+func (fn CredentialPrompterFunc) PromptCredential(ctx context.Context, req CredentialRequest) (string, error) {
+	return fn(ctx, req)
+}
+
+// PromptCredentials answers CTRL-REQ events with credentials from p.  Each
+// request runs in its own goroutine and is abandoned after timeout, or
+// never if it is zero.
+func PromptCredentials(p CredentialPrompter, timeout time.Duration) Option {
+	return func(conn *unixgram) error {
+		conn.prompter = p
+		conn.promptTimeout = timeout
+		conn.prompts = make(map[string]*pendingPrompt)
+		return nil
+	}
+}
+
+// pendingPrompt is a request being answered by the prompter.
+type pendingPrompt struct {
+	cancel context.CancelFunc
+}
+
+// prompt asks the prompter for a credential and sends the response.
+func (uc *unixgram) prompt(req *CredentialRequest) {
+	key := req.Interface + "/" + req.Field + "-" + strconv.Itoa(req.NetworkID)
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if uc.promptTimeout > 0 {
+		ctx, cancel = context.WithTimeout(uc.ctx, uc.promptTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(uc.ctx)
+	}
+	defer cancel()
+
+	// A repeated request supersedes the previous one.
+	p := &pendingPrompt{cancel: cancel}
+	uc.promptLock.Lock()
+	if prev, ok := uc.prompts[key]; ok {
+		prev.cancel()
+	}
+	uc.prompts[key] = p
+	uc.promptLock.Unlock()
+
+	value, err := uc.prompter.PromptCredential(ctx, *req)
+
+	uc.promptLock.Lock()
+	if uc.prompts[key] == p {
+		delete(uc.prompts, key)
+	}
+	uc.promptLock.Unlock()
+
+	if err != nil || ctx.Err() != nil {
+		return
+	}
+
+	uc.viewLock.Lock()
+	global := uc.views != nil
+	uc.viewLock.Unlock()
+
+	rsp := "CTRL-RSP-" + req.Field + "-" + strconv.Itoa(req.NetworkID) + ":" + value
+	if req.Interface != "" && global {
+		// Answer on the global control interface.
+		rsp = "IFNAME=" + req.Interface + " " + rsp
+	}
+	_ = uc.runCommand(rsp)
+}
+
+// cancelPrompts abandons all pending prompts.
+func (uc *unixgram) cancelPrompts() {
+	uc.promptLock.Lock()
+	defer uc.promptLock.Unlock()
+
+	for key, p := range uc.prompts {
+		p.cancel()
+		delete(uc.prompts, key)
+	}
+}
+
+func (uc *unixgram) SendCredential(field string, networkID int, value string) error {
+	return uc.runCommand("CTRL-RSP-" + field + "-" + strconv.Itoa(networkID) + ":" + value)
+}
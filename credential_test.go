@@ -0,0 +1,71 @@
+package wpasupplicant_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-laeo/wpasupplicant"
+	"github.com/go-laeo/wpasupplicant/wpatest"
+)
+
+// waitCommand waits for the server to receive a command.
+func waitCommand(t *testing.T, srv *wpatest.Server, cmd string) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, c := range srv.Commands() {
+			if c == cmd {
+				return
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("%q not received", cmd)
+}
+
+func TestPromptCredentials(t *testing.T) {
+	requests := make(chan wpasupplicant.CredentialRequest, 1)
+	prompter := wpasupplicant.CredentialPrompterFunc(func(ctx context.Context, req wpasupplicant.CredentialRequest) (string, error) {
+		requests <- req
+		return "s3cret", nil
+	})
+	srv, _ := connect(t, wpasupplicant.PromptCredentials(prompter, time.Second))
+
+	srv.Emit("CTRL-REQ-PASSWORD-2:Password needed for SSID corp")
+	waitCommand(t, srv, "CTRL-RSP-PASSWORD-2:s3cret")
+
+	req := <-requests
+	if req.Field != wpasupplicant.CredentialPassword || req.NetworkID != 2 || req.Text != "Password needed for SSID corp" {
+		t.Errorf("got %+v", req)
+	}
+}
+
+func TestPromptCredentialsTimeout(t *testing.T) {
+	done := make(chan error, 1)
+	prompter := wpasupplicant.CredentialPrompterFunc(func(ctx context.Context, req wpasupplicant.CredentialRequest) (string, error) {
+		<-ctx.Done()
+		done <- ctx.Err()
+		return "too late", nil
+	})
+	srv, _ := connect(t, wpasupplicant.PromptCredentials(prompter, 50*time.Millisecond))
+
+	srv.Emit("CTRL-REQ-OTP-0:Challenge 1234 needed for SSID corp")
+
+	select {
+	case err := <-done:
+		if err != context.DeadlineExceeded {
+			t.Errorf("prompt ended with %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("prompt was not cancelled")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	for _, c := range srv.Commands() {
+		if c == "CTRL-RSP-OTP-0:too late" {
+			t.Error("response sent after timeout")
+		}
+	}
+}
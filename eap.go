@@ -0,0 +1,220 @@
+package wpasupplicant
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// EAP event names.
+const (
+	EventEAPStarted      = "EAP-STARTED"
+	EventEAPMethod       = "EAP-METHOD"
+	EventEAPSuccess      = "EAP-SUCCESS"
+	EventEAPFailure      = "EAP-FAILURE"
+	EventEAPPeerCert     = "EAP-PEER-CERT"
+	EventEAPTLSCertError = "EAP-TLS-CERT-ERROR"
+)
+
+// EAPMethod is an EAP method, as used in the eap network variable.
+type EAPMethod string
+
+const (
+	EAPTLS      EAPMethod = "TLS"
+	EAPPEAP     EAPMethod = "PEAP"
+	EAPTTLS     EAPMethod = "TTLS"
+	EAPPWD      EAPMethod = "PWD"
+	EAPSIM      EAPMethod = "SIM"
+	EAPAKA      EAPMethod = "AKA"
+	EAPAKAPrime EAPMethod = "AKA'"
+)
+
+// EAPConfig is the 802.1X/EAP configuration of a network.  Which fields
+// are required depends on the method; see Validate.
+type EAPConfig struct {
+	Method EAPMethod
+
+	// Identity is the user name, and AnonymousIdentity the outer identity
+	// sent in the clear by tunnelled methods.
+	Identity          string
+	AnonymousIdentity string
+
+	// Password is the password for PEAP, TTLS and PWD, or the Milenage
+	// parameters for SIM and AKA without a smart card.  PasswordHash
+	// may be given instead for MSCHAPv2: it is the 16 byte NtPasswordHash.
+	Password     string
+	PasswordHash []byte
+
+	// Phase1 and Phase2 are the parameters of the outer and inner
+	// authentication, e.g. "peapver=0" and "auth=MSCHAPV2".  Phase2
+	// defaults to "auth=MSCHAPV2" for PEAP and TTLS.
+	Phase1 string
+	Phase2 string
+
+	// CACert is the path of the CA certificate used to validate the
+	// server.  DomainSuffixMatch and DomainMatch constrain the server
+	// certificate's name.
+	CACert            string
+	DomainSuffixMatch string
+	DomainMatch       string
+
+	// ClientCert, PrivateKey and PrivateKeyPassword are the client
+	// certificate and key for TLS.
+	ClientCert         string
+	PrivateKey         string
+	PrivateKeyPassword string
+
+	// PCSC uses the SIM card through PC/SC for SIM and AKA, unlocked with
+	// PIN.
+	PCSC bool
+	PIN  string
+}
+
+// Validate checks that the fields required by the method are set.
+func (c *EAPConfig) Validate() error {
+	if c.Password != "" && c.PasswordHash != nil {
+		return errors.New("EAP: both password and password hash given")
+	}
+	if c.PasswordHash != nil && len(c.PasswordHash) != 16 {
+		return errors.New("EAP: password hash must be 16 bytes")
+	}
+
+	switch c.Method {
+	case EAPTLS:
+		if c.Identity == "" {
+			return errors.New("EAP-TLS: identity required")
+		}
+		if c.ClientCert == "" || c.PrivateKey == "" {
+			return errors.New("EAP-TLS: client certificate and private key required")
+		}
+	case EAPPEAP, EAPTTLS:
+		if c.Identity == "" {
+			return fmt.Errorf("EAP-%s: identity required", c.Method)
+		}
+		if c.Password == "" && c.PasswordHash == nil {
+			return fmt.Errorf("EAP-%s: password required", c.Method)
+		}
+		if c.PasswordHash != nil && !strings.Contains(c.phase2(), "MSCHAPV2") {
+			return fmt.Errorf("EAP-%s: password hash requires MSCHAPV2", c.Method)
+		}
+	case EAPPWD:
+		if c.Identity == "" || c.Password == "" {
+			return errors.New("EAP-PWD: identity and password required")
+		}
+		if c.PasswordHash != nil {
+			return errors.New("EAP-PWD: password hash not supported")
+		}
+	case EAPSIM, EAPAKA, EAPAKAPrime:
+		if !c.PCSC && c.Password == "" {
+			return fmt.Errorf("EAP-%s: smart card or Milenage parameters required", c.Method)
+		}
+	case "":
+		return errors.New("EAP: method required")
+	default:
+		return fmt.Errorf("EAP: unsupported method %q", c.Method)
+	}
+
+	return nil
+}
+
+// phase2 returns the phase 2 parameters, with the default for the method.
+func (c *EAPConfig) phase2() string {
+	if c.Phase2 == "" && (c.Method == EAPPEAP || c.Method == EAPTTLS) {
+		return "auth=MSCHAPV2"
+	}
+	return c.Phase2
+}
+
+// variables returns the network variables which configure EAP.
+func (c *EAPConfig) variables() [][2]interface{} {
+	vars := [][2]interface{}{{"eap", string(c.Method)}}
+
+	add := func(name, value string) {
+		if value != "" {
+			vars = append(vars, [2]interface{}{name, value})
+		}
+	}
+	add("identity", c.Identity)
+	add("anonymous_identity", c.AnonymousIdentity)
+	add("password", c.Password)
+	if c.PasswordHash != nil {
+		add("password", "hash:"+hex.EncodeToString(c.PasswordHash))
+	}
+	add("phase1", c.Phase1)
+	add("phase2", c.phase2())
+	add("ca_cert", c.CACert)
+	add("domain_suffix_match", c.DomainSuffixMatch)
+	add("domain_match", c.DomainMatch)
+	add("client_cert", c.ClientCert)
+	add("private_key", c.PrivateKey)
+	add("private_key_passwd", c.PrivateKeyPassword)
+	if c.PCSC {
+		vars = append(vars, [2]interface{}{"pcsc", ""})
+	}
+	add("pin", c.PIN)
+
+	return vars
+}
+
+// EAPEvent is a CTRL-EVENT-EAP-* event.  Only the fields relevant to the
+// event are set.
+type EAPEvent struct {
+	// Event is one of the EventEAP constants.
+	Event string
+
+	// Method and MethodType are the method selected, e.g. "PEAP" and 25,
+	// for EAP-METHOD.
+	Method     string
+	MethodType int
+
+	// Depth, Subject and Hash describe a certificate in the server's
+	// chain, for EAP-PEER-CERT and EAP-TLS-CERT-ERROR.  Depth 0 is the
+	// server certificate.  Cert is the DER encoded certificate, if
+	// wpa_supplicant reports it.
+	Depth   int
+	Subject string
+	Hash    string
+	Cert    []byte
+
+	// Reason and Error describe why the certificate was rejected, for
+	// EAP-TLS-CERT-ERROR.
+	Reason int
+	Error  string
+}
+
+// eapMethodSelected extracts the method from EAP-METHOD, e.g. "EAP vendor 0
+// method 25 (PEAP) selected".
+var eapMethodSelected = regexp.MustCompile(`method (\d+) \(([^)]*)\)`)
+
+// ParseEAPEvent returns the EAPEvent for e, or false if e isn't an EAP
+// event.
+func ParseEAPEvent(e WPAEvent) (*EAPEvent, bool) {
+	switch e.Event {
+	case EventEAPStarted, EventEAPSuccess, EventEAPFailure:
+		return &EAPEvent{Event: e.Event}, true
+	case EventEAPMethod:
+		ev := &EAPEvent{Event: e.Event}
+		if m := eapMethodSelected.FindStringSubmatch(e.Line); m != nil {
+			ev.MethodType, _ = strconv.Atoi(m[1])
+			ev.Method = m[2]
+		}
+		return ev, true
+	case EventEAPPeerCert, EventEAPTLSCertError:
+		ev := &EAPEvent{
+			Event:   e.Event,
+			Subject: e.Arguments["subject"],
+			Hash:    e.Arguments["hash"],
+			Error:   e.Arguments["err"],
+		}
+		ev.Depth, _ = strconv.Atoi(e.Arguments["depth"])
+		ev.Reason, _ = strconv.Atoi(e.Arguments["reason"])
+		if cert, ok := e.Arguments["cert"]; ok {
+			ev.Cert, _ = hex.DecodeString(cert)
+		}
+		return ev, true
+	}
+	return nil, false
+}
@@ -0,0 +1,93 @@
+package wpasupplicant_test
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/go-laeo/wpasupplicant"
+)
+
+func TestCreateNetworkEAP(t *testing.T) {
+	srv, conn := connect(t)
+
+	bssid, _ := net.ParseMAC("02:00:00:00:01:00")
+	id, err := conn.CreateNetwork(wpasupplicant.NetworkConfig{
+		SSID:  "corp",
+		BSSID: bssid,
+		PMF:   wpasupplicant.PMFOptional,
+		EAP: &wpasupplicant.EAPConfig{
+			Method:            wpasupplicant.EAPPEAP,
+			Identity:          "alice",
+			AnonymousIdentity: "anonymous@example.com",
+			PasswordHash:      bytes.Repeat([]byte{0xab}, 16),
+			CACert:            "/etc/ssl/corp-ca.pem",
+			DomainSuffixMatch: "radius.example.com",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vars := srv.Network(id)
+	for k, v := range map[string]string{
+		"ssid":                "636f7270",
+		"bssid":               "02:00:00:00:01:00",
+		"key_mgmt":            "WPA-EAP",
+		"ieee80211w":          "1",
+		"eap":                 "PEAP",
+		"identity":            `"alice"`,
+		"password":            "hash:abababababababababababababababab",
+		"phase2":              `"auth=MSCHAPV2"`,
+		"ca_cert":             `"/etc/ssl/corp-ca.pem"`,
+		"domain_suffix_match": `"radius.example.com"`,
+	} {
+		if vars[k] != v {
+			t.Errorf("%s: got %q, expect %q", k, vars[k], v)
+		}
+	}
+}
+
+func TestNetworkConfigValidate(t *testing.T) {
+	for _, c := range []wpasupplicant.NetworkConfig{
+		{SSID: ""},
+		{SSID: "psk", KeyMgmt: wpasupplicant.PSK},
+		{SSID: "short", PSK: "1234567"},
+		{SSID: "sae", SAEPassword: "secret", PMF: wpasupplicant.PMFDisabled},
+		{SSID: "eap", KeyMgmt: wpasupplicant.IEEE8021X},
+		{SSID: "eap", PSK: "12345678", EAP: &wpasupplicant.EAPConfig{Method: wpasupplicant.EAPPWD, Identity: "a", Password: "b"}},
+		{SSID: "tls", EAP: &wpasupplicant.EAPConfig{Method: wpasupplicant.EAPTLS, Identity: "a"}},
+		{SSID: "peap", EAP: &wpasupplicant.EAPConfig{Method: wpasupplicant.EAPPEAP, Identity: "a"}},
+		{SSID: "ttls", EAP: &wpasupplicant.EAPConfig{Method: wpasupplicant.EAPTTLS, Identity: "a", PasswordHash: make([]byte, 16), Phase2: "auth=PAP"}},
+		{SSID: "sim", EAP: &wpasupplicant.EAPConfig{Method: wpasupplicant.EAPSIM}},
+		{SSID: "md5", EAP: &wpasupplicant.EAPConfig{Method: "MD5"}},
+	} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("%+v: expected an error", c)
+		}
+	}
+
+	valid := wpasupplicant.NetworkConfig{SSID: "sim", EAP: &wpasupplicant.EAPConfig{Method: wpasupplicant.EAPAKA, PCSC: true, PIN: "1234"}}
+	if err := valid.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEAPEvents(t *testing.T) {
+	srv, conn := connect(t)
+
+	events, unsubscribe := conn.Subscribe()
+	defer unsubscribe()
+
+	srv.Emit("CTRL-EVENT-EAP-METHOD EAP vendor 0 method 25 (PEAP) selected")
+	e, ok := wpasupplicant.ParseEAPEvent(waitEvent(t, events, wpasupplicant.EventEAPMethod))
+	if !ok || e.Method != "PEAP" || e.MethodType != 25 {
+		t.Errorf("got %+v", e)
+	}
+
+	srv.Emit("CTRL-EVENT-EAP-TLS-CERT-ERROR reason=4 depth=0 subject='/C=US/CN=radius.example.com' err='certificate has expired'")
+	e, ok = wpasupplicant.ParseEAPEvent(waitEvent(t, events, wpasupplicant.EventEAPTLSCertError))
+	if !ok || e.Reason != 4 || e.Subject != "/C=US/CN=radius.example.com" || e.Error != "certificate has expired" {
+		t.Errorf("got %+v", e)
+	}
+}
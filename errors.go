@@ -0,0 +1,91 @@
+package wpasupplicant
+
+import (
+	"errors"
+	"strings"
+)
+
+// Failure codes of a CommandError.  Use errors.Is to check for them.
+var (
+	// ErrFail is a FAIL reply.  Every FAIL-<reason> reply also matches
+	// ErrFail, besides its specific code.
+	ErrFail = errors.New("command failed")
+
+	// ErrBusy is a FAIL-BUSY reply: wpa_supplicant is already doing what
+	// was asked, e.g. scanning.
+	ErrBusy = errors.New("wpa_supplicant is busy")
+
+	// ErrUnknownCommand is an UNKNOWN COMMAND reply, e.g. from an older
+	// version of wpa_supplicant.
+	ErrUnknownCommand = errors.New("unknown command")
+
+	// ErrTooLong is returned for commands longer than wpa_supplicant
+	// accepts, or a FAIL-TOO-LONG reply.
+	ErrTooLong = errors.New("command too long")
+
+	// ErrPermission is returned when the control socket can't be written
+	// to, or for a FAIL-PERMISSION reply.
+	ErrPermission = errors.New("permission denied")
+)
+
+// maxCommandLen is the longest command wpa_supplicant reads.
+const maxCommandLen = 4096
+
+// replyCodes are the codes of the failure replies.  Other FAIL-<reason>
+// replies are ErrFail.
+var replyCodes = map[string]error{
+	"FAIL":            ErrFail,
+	"FAIL-BUSY":       ErrBusy,
+	"FAIL-CHECKSUM":   ErrWPSPinChecksum,
+	"FAIL-TOO-LONG":   ErrTooLong,
+	"FAIL-PERMISSION": ErrPermission,
+	"UNKNOWN COMMAND": ErrUnknownCommand,
+}
+
+// CommandError is returned when wpa_supplicant rejects a command.
+type CommandError struct {
+	// Command is the command, with any secret redacted.
+	Command string
+
+	// Reply is wpa_supplicant's reply, e.g. "FAIL-BUSY", or empty if
+	// the command wasn't sent.
+	Reply string
+
+	// Code is one of the failure codes, e.g. ErrBusy.
+	Code error
+}
+
+func (err *CommandError) Error() string {
+	name := err.Command
+	if i := strings.IndexByte(name, ' '); i != -1 {
+		name = name[:i]
+	}
+	if err.Reply == "" {
+		return name + ": " + err.Code.Error()
+	}
+	return name + ": " + err.Code.Error() + " (" + err.Reply + ")"
+}
+
+// Unwrap returns the failure code.
+func (err *CommandError) Unwrap() error {
+	return err.Code
+}
+
+// Is matches ErrFail for every FAIL-<reason> reply.
+func (err *CommandError) Is(target error) bool {
+	return target == ErrFail && strings.HasPrefix(err.Reply, "FAIL")
+}
+
+// replyError returns a CommandError if resp is a failure reply to cmd, or
+// nil.
+func replyError(cmd string, resp []byte) error {
+	reply := strings.TrimSuffix(string(resp), "\n")
+	code, ok := replyCodes[reply]
+	if !ok {
+		if !strings.HasPrefix(reply, "FAIL-") || strings.ContainsAny(reply, " \n") {
+			return nil
+		}
+		code = ErrFail
+	}
+	return &CommandError{Command: redactCommand(cmd), Reply: reply, Code: code}
+}
@@ -0,0 +1,70 @@
+package wpasupplicant_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-laeo/wpasupplicant"
+	"github.com/go-laeo/wpasupplicant/wpatest"
+)
+
+func TestCommandErrors(t *testing.T) {
+	srv, conn := connect(t)
+
+	tests := []struct {
+		reply string
+		code  error
+		fail  bool
+	}{
+		{"FAIL\n", wpasupplicant.ErrFail, true},
+		{"FAIL-BUSY\n", wpasupplicant.ErrBusy, true},
+		{"FAIL-CHECKSUM\n", wpasupplicant.ErrWPSPinChecksum, true},
+		{"FAIL-TOO-LONG\n", wpasupplicant.ErrTooLong, true},
+		{"FAIL-SOMETHING\n", wpasupplicant.ErrFail, true},
+		{"UNKNOWN COMMAND\n", wpasupplicant.ErrUnknownCommand, false},
+	}
+	for _, test := range tests {
+		srv.Inject("REASSOCIATE", wpatest.Fault{Reply: test.reply})
+		err := conn.Reassociate()
+
+		var cerr *wpasupplicant.CommandError
+		if !errors.As(err, &cerr) || cerr.Command != "REASSOCIATE" || cerr.Reply != strings.TrimSuffix(test.reply, "\n") {
+			t.Errorf("%q: got %#v", test.reply, err)
+			continue
+		}
+		if !errors.Is(err, test.code) {
+			t.Errorf("%q: %v is not %v", test.reply, err, test.code)
+		}
+		if errors.Is(err, wpasupplicant.ErrFail) != test.fail {
+			t.Errorf("%q: errors.Is(%v, ErrFail) != %v", test.reply, err, test.fail)
+		}
+	}
+
+	// The wrong checksum of CheckWPSPin is still ErrWPSPinChecksum.
+	srv.Inject("WPS_CHECK_PIN", wpatest.Fault{Reply: "FAIL-CHECKSUM\n"})
+	if _, err := conn.CheckWPSPin("12345678"); !errors.Is(err, wpasupplicant.ErrWPSPinChecksum) {
+		t.Errorf("got %v", err)
+	}
+
+	// Secrets don't leak into errors.
+	id, err := conn.AddNetwork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Inject("SET_NETWORK", wpatest.Fault{Reply: "FAIL\n"})
+	err = conn.SetNetwork(id, "psk", "hunter22")
+	if err == nil || strings.Contains(err.Error(), "hunter22") {
+		t.Errorf("got %v", err)
+	}
+
+	// Commands too long aren't sent.
+	n := len(srv.Commands())
+	err = conn.SetNetwork(id, "ssid", strings.Repeat("x", 5000))
+	if !errors.Is(err, wpasupplicant.ErrTooLong) || errors.Is(err, wpasupplicant.ErrFail) {
+		t.Errorf("got %v", err)
+	}
+	if len(srv.Commands()) != n {
+		t.Error("sent a command too long")
+	}
+}
@@ -0,0 +1,212 @@
+package wpasupplicant
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"strings"
+)
+
+// GlobalConn is a connection to the global control interface of
+// wpa_supplicant, i.e. the socket given with -g, e.g.
+// /run/wpa_supplicant-global.  It manages the interfaces wpa_supplicant
+// controls, and hands out per-interface views which send their commands
+// over the global socket.
+type GlobalConn interface {
+	// Close closes the connection.  Interface views stop working.
+	Close() error
+
+	// Ping tests the connection.  It returns nil if wpa_supplicant is
+	// responding.
+	Ping() error
+
+	// Interfaces returns the interfaces wpa_supplicant is controlling.
+	Interfaces() ([]string, error)
+
+	// InterfaceAdd starts controlling a new interface.
+	InterfaceAdd(InterfaceConfig) error
+
+	// InterfaceRemove stops controlling an interface.
+	InterfaceRemove(iface string) error
+
+	// InterfaceList returns the interfaces known to the drivers, which
+	// may be passed to InterfaceAdd.
+	InterfaceList() ([]string, error)
+
+	// Terminate asks wpa_supplicant to exit.
+	Terminate() error
+
+	// Suspend notifies wpa_supplicant that the system is going to sleep.
+	Suspend() error
+
+	// Resume notifies wpa_supplicant that the system woke up.
+	Resume() error
+
+	// Interface returns a connection which sends its commands to iface
+	// over the global socket.  Its events are those the global socket
+	// receives for iface, and it answers their credential requests with
+	// the PromptCredentials prompter, if any.  Closing it doesn't affect
+	// the global connection.
+	Interface(iface string) Conn
+
+	// EventQueue receives the events for all interfaces.  WPAEvent.Interface
+	// is set to the interface each event concerns, if any.
+	EventQueue() chan WPAEvent
+
+	// Subscribe is like Conn.Subscribe, for the events of all interfaces.
+	Subscribe() (<-chan WPAEvent, func())
+}
+
+// InterfaceConfig describes an interface to add with INTERFACE_ADD.  Only
+// Ifname is required.
+type InterfaceConfig struct {
+	// Ifname is the name of the network interface, e.g. "wlan1".
+	Ifname string
+
+	// ConfName is the path of the configuration file, if any.
+	ConfName string
+
+	// Driver is the driver name, e.g. "nl80211".
+	Driver string
+
+	// CtrlInterface is the ctrl_interface to use if ConfName is not set,
+	// e.g. "/run/wpa_supplicant".
+	CtrlInterface string
+
+	// DriverParam is passed to the driver.
+	DriverParam string
+
+	// BridgeName is the bridge the interface belongs to, if any.
+	BridgeName string
+
+	// Create asks wpa_supplicant to create the interface first.
+	Create bool
+
+	// Type is the type of interface to create, "sta" or "ap".
+	Type string
+}
+
+// command formats the config as the argument of INTERFACE_ADD, which is a
+// tab separated list of fields.  Trailing empty fields are omitted.
+func (c InterfaceConfig) command() string {
+	var create string
+	if c.Create {
+		create = "create"
+	}
+
+	fields := []string{c.Ifname, c.ConfName, c.Driver, c.CtrlInterface, c.DriverParam, c.BridgeName, create, c.Type}
+	for len(fields) > 1 && fields[len(fields)-1] == "" {
+		fields = fields[:len(fields)-1]
+	}
+
+	return "INTERFACE_ADD " + strings.Join(fields, "\t")
+}
+
+// globalConn is the implementation of GlobalConn.
+type globalConn struct {
+	*unixgram
+}
+
+var _ GlobalConn = (*globalConn)(nil)
+
+// ConnectGlobal connects to the global control interface at ctrlPath.
+func ConnectGlobal(ctx context.Context, ctrlPath string, options ...Option) (GlobalConn, error) {
+	local, err := createLocalPath("global")
+	if err != nil {
+		return nil, err
+	}
+
+	// The views must exist before dial starts delivering events.
+	views := func(conn *unixgram) error {
+		conn.views = make(map[string]*unixgram)
+		return nil
+	}
+
+	uc, err := dial(ctx, local, ctrlPath, append([]Option{views}, options...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &globalConn{uc}, nil
+}
+
+func (g *globalConn) Interfaces() ([]string, error) {
+	resp, err := g.cmd("INTERFACES")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseLines(resp), nil
+}
+
+func (g *globalConn) InterfaceAdd(c InterfaceConfig) error {
+	return g.runCommand(c.command())
+}
+
+func (g *globalConn) InterfaceRemove(iface string) error {
+	return g.runCommand("INTERFACE_REMOVE " + iface)
+}
+
+func (g *globalConn) InterfaceList() ([]string, error) {
+	resp, err := g.cmd("INTERFACE_LIST")
+	if err != nil {
+		return nil, err
+	}
+
+	// Each line is the interface name, optionally followed by a tab and
+	// a description.
+	var ifaces []string
+	for _, ln := range parseLines(resp) {
+		ifaces = append(ifaces, strings.SplitN(ln, "\t", 2)[0])
+	}
+	return ifaces, nil
+}
+
+func (g *globalConn) Terminate() error {
+	return g.runCommand("TERMINATE")
+}
+
+func (g *globalConn) Suspend() error {
+	return g.runCommand("SUSPEND")
+}
+
+func (g *globalConn) Resume() error {
+	return g.runCommand("RESUME")
+}
+
+func (g *globalConn) Interface(iface string) Conn {
+	g.viewLock.Lock()
+	defer g.viewLock.Unlock()
+
+	if v, ok := g.views[iface]; ok {
+		return v
+	}
+
+	v := &unixgram{
+		ctx:           g.ctx,
+		wpaEvents:     make(chan WPAEvent),
+		subscribers:   make(map[chan WPAEvent]struct{}),
+		parent:        g.unixgram,
+		ifname:        iface,
+		prompter:      g.prompter,
+		promptTimeout: g.promptTimeout,
+	}
+	if v.prompter != nil {
+		v.prompts = make(map[string]*pendingPrompt)
+	}
+	g.views[iface] = v
+
+	return v
+}
+
+// parseLines splits a reply into its non-empty lines.
+func parseLines(resp []byte) []string {
+	var lines []string
+	s := bufio.NewScanner(bytes.NewReader(resp))
+	for s.Scan() {
+		if ln := strings.TrimSpace(s.Text()); ln != "" {
+			lines = append(lines, ln)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,155 @@
+package wpasupplicant_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-laeo/wpasupplicant"
+	"github.com/go-laeo/wpasupplicant/wpatest"
+)
+
+func TestGlobalConn(t *testing.T) {
+	srv, err := wpatest.NewServer("global")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	ifaces := []string{"wlan0"}
+	srv.Handle("INTERFACES", func(string) (string, []string) {
+		return strings.Join(ifaces, "\n") + "\n", nil
+	})
+	srv.Handle("INTERFACE_ADD", func(args string) (string, []string) {
+		ifaces = append(ifaces, strings.Split(args, "\t")[0])
+		return "OK\n", nil
+	})
+	srv.Handle("IFNAME=wlan1", func(args string) (string, []string) {
+		if args == "PING" {
+			return "PONG\n", nil
+		}
+		return "UNKNOWN COMMAND\n", nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	g, err := wpasupplicant.ConnectGlobal(ctx, srv.Path(), wpasupplicant.CommandTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+
+	if err := g.InterfaceAdd(wpasupplicant.InterfaceConfig{Ifname: "wlan1", Driver: "nl80211"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := g.Interfaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "wlan0,wlan1" {
+		t.Errorf("got interfaces %q", got)
+	}
+
+	wlan1 := g.Interface("wlan1")
+	if err := wlan1.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	events, unsubscribe := wlan1.Subscribe()
+	defer unsubscribe()
+
+	srv.EmitInterface("wlan0", "CTRL-EVENT-SCAN-STARTED ")
+	srv.EmitInterface("wlan1", "CTRL-EVENT-SCAN-RESULTS ")
+	e := waitEvent(t, events, "SCAN-RESULTS")
+	if e.Interface != "wlan1" {
+		t.Errorf("event for %q delivered to wlan1", e.Interface)
+	}
+
+}
+
+func TestGlobalConnPromptCredentials(t *testing.T) {
+	srv, err := wpatest.NewServer("global")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	srv.Handle("IFNAME=wlan1", func(args string) (string, []string) {
+		return "OK\n", nil
+	})
+
+	requests := make(chan wpasupplicant.CredentialRequest, 2)
+	prompter := wpasupplicant.CredentialPrompterFunc(func(ctx context.Context, req wpasupplicant.CredentialRequest) (string, error) {
+		requests <- req
+		return "s3cret", nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	g, err := wpasupplicant.ConnectGlobal(ctx, srv.Path(), wpasupplicant.CommandTimeout(time.Second),
+		wpasupplicant.PromptCredentials(prompter, time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+
+	// Requests for an interface without a view are answered by the
+	// global connection, and the others by the view.
+	srv.EmitInterface("wlan0", "CTRL-REQ-PASSWORD-1:Password needed for SSID corp")
+	waitCommand(t, srv, "IFNAME=wlan0 CTRL-RSP-PASSWORD-1:s3cret")
+
+	wlan1 := g.Interface("wlan1")
+	defer wlan1.Close()
+	srv.EmitInterface("wlan1", "CTRL-REQ-PASSWORD-2:Password needed for SSID lab")
+	waitCommand(t, srv, "IFNAME=wlan1 CTRL-RSP-PASSWORD-2:s3cret")
+
+	for _, iface := range []string{"wlan0", "wlan1"} {
+		if req := <-requests; req.Interface != iface {
+			t.Errorf("got request for %q, expect %q", req.Interface, iface)
+		}
+	}
+	select {
+	case req := <-requests:
+		t.Errorf("request prompted twice: %+v", req)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestGlobalConnRecordRedactsPIN(t *testing.T) {
+	srv, err := wpatest.NewServer("global")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	srv.Handle("IFNAME=wlan0", func(args string) (string, []string) {
+		if strings.HasPrefix(args, "WPS_PIN ") {
+			return "12345670", nil
+		}
+		return "OK\n", nil
+	})
+
+	trace := &bytes.Buffer{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	g, err := wpasupplicant.ConnectGlobal(ctx, srv.Path(), wpasupplicant.CommandTimeout(time.Second), wpasupplicant.Record(trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+
+	// No WPS events follow, so the attempt times out after the reply.
+	wctx, wcancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer wcancel()
+	_, _ = g.Interface("wlan0").WPSPin(wctx, nil, "12345670")
+	waitCommand(t, srv, "IFNAME=wlan0 WPS_PIN any 12345670")
+
+	if strings.Contains(trace.String(), "12345670") {
+		t.Errorf("trace contains the PIN:\n%s", trace)
+	}
+	if !strings.Contains(trace.String(), "IFNAME=wlan0 WPS_PIN any "+wpasupplicant.Redacted) {
+		t.Errorf("command not recorded:\n%s", trace)
+	}
+}
@@ -0,0 +1,55 @@
+package wpasupplicant_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-laeo/wpasupplicant"
+	"github.com/go-laeo/wpasupplicant/wpatest"
+)
+
+// connect starts a fake server and connects to it, closing both when the
+// test ends.
+func connect(t *testing.T, options ...wpasupplicant.Option) (*wpatest.Server, wpasupplicant.Conn) {
+	t.Helper()
+
+	srv, err := wpatest.NewServer("wlan0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	options = append([]wpasupplicant.Option{wpasupplicant.CommandTimeout(time.Second)}, options...)
+	conn, err := wpasupplicant.ConnectPath(ctx, srv.Dir(), srv.Iface(), options...)
+	if err != nil {
+		cancel()
+		srv.Close()
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		conn.Close()
+		cancel()
+		srv.Close()
+	})
+
+	return srv, conn
+}
+
+// waitEvent waits for an event with the given name.
+func waitEvent(t *testing.T, events <-chan wpasupplicant.WPAEvent, name string) wpasupplicant.WPAEvent {
+	t.Helper()
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case e := <-events:
+			if e.Event == name {
+				return e
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s", name)
+		}
+	}
+}
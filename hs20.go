@@ -0,0 +1,214 @@
+package wpasupplicant
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Hotspot 2.0 (Passpoint) event names.
+const (
+	EventInterworkingAP              = "INTERWORKING-AP"
+	EventInterworkingNoMatch         = "INTERWORKING-NO-MATCH"
+	EventInterworkingSelected        = "INTERWORKING-SELECTED"
+	EventANQPQueryDone               = "ANQP-QUERY-DONE"
+	EventRXANQP                      = "RX-ANQP"
+	EventRXHS20ANQP                  = "RX-HS20-ANQP"
+	EventHS20SubscriptionRemediation = "HS20-SUBSCRIPTION-REMEDIATION"
+)
+
+// ANQP info IDs, for ANQPGet.
+const (
+	ANQPCapabilityList         = 257
+	ANQPVenueName              = 258
+	ANQPNetworkAuthType        = 260
+	ANQPRoamingConsortium      = 261
+	ANQPIPAddrTypeAvailability = 262
+	ANQPNAIRealm               = 263
+	ANQP3GPPCellularNetwork    = 264
+	ANQPDomainName             = 268
+	ANQPVenueURL               = 277
+)
+
+// unquotedCredVariables are the credential variables whose string values
+// are keywords or hex, which SetCred doesn't quote.
+var unquotedCredVariables = map[string]bool{
+	"eap":                         true,
+	"roaming_consortium":          true,
+	"required_roaming_consortium": true,
+	"roaming_consortiums":         true,
+	"req_conn_capab":              true,
+}
+
+// Cred is a Hotspot 2.0 credential, from LIST_CREDS.
+type Cred struct {
+	ID       int    `json:"id"`
+	Realm    string `json:"realm"`
+	Username string `json:"username"`
+	Domain   string `json:"domain"`
+	IMSI     string `json:"imsi"`
+}
+
+// HS20Event is an interworking, ANQP or Hotspot 2.0 event.  Only the fields
+// relevant to the event are set.
+type HS20Event struct {
+	// Event is one of the Hotspot 2.0 event constants.
+	Event string
+
+	// BSSID is the access point concerned.
+	BSSID net.HardwareAddr
+
+	// Type is "home", "roaming" or "unknown", for INTERWORKING-AP.
+	Type string
+
+	// CredID is the matching credential, or -1, for INTERWORKING-AP.
+	CredID int
+
+	// Priority and SPPriority are the priorities of the matching
+	// credential, for INTERWORKING-AP.
+	Priority   int
+	SPPriority int
+
+	// BelowMinBackhaul, OverMaxBSSLoad and ConnCapabMissing tell which
+	// of the credential's requirements the AP fails, for
+	// INTERWORKING-AP.
+	BelowMinBackhaul bool
+	OverMaxBSSLoad   bool
+	ConnCapabMissing bool
+
+	// Success is the result of ANQP-QUERY-DONE.
+	Success bool
+
+	// Info is the element received, e.g. "Venue Name" or "WAN Metrics",
+	// for RX-ANQP and RX-HS20-ANQP.
+	Info string
+
+	// OSUMethod and URL are the online sign-up method (0 for OMA-DM, 1
+	// for SOAP-XML SPP) and server, for HS20-SUBSCRIPTION-REMEDIATION.
+	OSUMethod int
+	URL       string
+}
+
+// ParseHS20Event returns the HS20Event for e, or false if e isn't a
+// Hotspot 2.0 event.
+func ParseHS20Event(e WPAEvent) (*HS20Event, bool) {
+	h := &HS20Event{Event: e.Event, CredID: -1}
+	fields := splitEventFields(e.Line)
+
+	switch e.Event {
+	case EventInterworkingAP, EventInterworkingSelected:
+		// INTERWORKING-AP <bssid> type=home id=0 priority=1 sp_priority=0 ...
+		if len(fields) > 1 {
+			h.BSSID, _ = net.ParseMAC(fields[1])
+		}
+		h.Type = e.Arguments["type"]
+		if id, err := strconv.Atoi(e.Arguments["id"]); err == nil {
+			h.CredID = id
+		}
+		h.Priority, _ = strconv.Atoi(e.Arguments["priority"])
+		h.SPPriority, _ = strconv.Atoi(e.Arguments["sp_priority"])
+		h.BelowMinBackhaul = e.Arguments["below_min_backhaul"] == "1"
+		h.OverMaxBSSLoad = e.Arguments["over_max_bss_load"] == "1"
+		h.ConnCapabMissing = e.Arguments["conn_capab_missing"] == "1"
+	case EventInterworkingNoMatch:
+	case EventANQPQueryDone:
+		// ANQP-QUERY-DONE addr=<bssid> result=SUCCESS
+		h.BSSID, _ = net.ParseMAC(e.Arguments["addr"])
+		h.Success = e.Arguments["result"] == "SUCCESS"
+	case EventRXANQP, EventRXHS20ANQP:
+		// RX-ANQP <bssid> <info>
+		if len(fields) > 1 {
+			h.BSSID, _ = net.ParseMAC(fields[1])
+		}
+		if len(fields) > 2 {
+			h.Info = strings.Join(fields[2:], " ")
+		}
+	case EventHS20SubscriptionRemediation:
+		// HS20-SUBSCRIPTION-REMEDIATION [<osu method> <url>]
+		if len(fields) > 2 {
+			h.OSUMethod, _ = strconv.Atoi(fields[1])
+			h.URL = fields[2]
+		}
+	default:
+		return nil, false
+	}
+
+	return h, true
+}
+
+func (uc *unixgram) AddCred() (int, error) {
+	resp, err := uc.cmd("ADD_CRED")
+	if err != nil {
+		return -1, err
+	}
+
+	id, err := strconv.Atoi(strings.TrimSpace(string(resp)))
+	if err != nil {
+		return -1, &ParseError{Line: string(resp), Err: err}
+	}
+	return id, nil
+}
+
+func (uc *unixgram) SetCred(credID int, variable string, value interface{}) error {
+	return uc.setVariable("SET_CRED", credID, variable, value, unquotedCredVariables)
+}
+
+func (uc *unixgram) RemoveCred(credID int) error {
+	return uc.runCommand("REMOVE_CRED " + strconv.Itoa(credID))
+}
+
+func (uc *unixgram) ListCreds() ([]Cred, error) {
+	resp, err := uc.cmd("LIST_CREDS")
+	if err != nil {
+		return nil, err
+	}
+
+	// The first line is the header "cred id / realm / username / domain
+	// / imsi".
+	lines := strings.Split(strings.TrimSuffix(string(resp), "\n"), "\n")
+	if !strings.HasPrefix(lines[0], "cred id") {
+		return nil, &ParseError{Line: lines[0]}
+	}
+
+	creds := []Cred{}
+	for _, ln := range lines[1:] {
+		if ln == "" {
+			continue
+		}
+
+		f := strings.Split(ln, "\t")
+		for len(f) < 5 {
+			f = append(f, "")
+		}
+		id, err := strconv.Atoi(f[0])
+		if err != nil {
+			return nil, &ParseError{Line: ln, Err: err}
+		}
+
+		creds = append(creds, Cred{ID: id, Realm: f[1], Username: f[2], Domain: f[3], IMSI: f[4]})
+	}
+	return creds, nil
+}
+
+func (uc *unixgram) InterworkingSelect(auto bool) error {
+	if auto {
+		return uc.runCommand("INTERWORKING_SELECT auto")
+	}
+	return uc.runCommand("INTERWORKING_SELECT")
+}
+
+func (uc *unixgram) InterworkingConnect(bssid net.HardwareAddr) error {
+	return uc.runCommand("INTERWORKING_CONNECT " + bssid.String())
+}
+
+func (uc *unixgram) ANQPGet(bssid net.HardwareAddr, infoIDs []int) error {
+	ids := make([]string, len(infoIDs))
+	for i, id := range infoIDs {
+		ids[i] = strconv.Itoa(id)
+	}
+	return uc.runCommand("ANQP_GET " + bssid.String() + " " + strings.Join(ids, ","))
+}
+
+func (uc *unixgram) HS20IconRequest(bssid net.HardwareAddr, filename string) error {
+	return uc.runCommand("HS20_ICON_REQUEST " + bssid.String() + " " + filename)
+}
@@ -0,0 +1,98 @@
+package wpasupplicant_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/go-laeo/wpasupplicant"
+)
+
+func TestCreds(t *testing.T) {
+	srv, conn := connect(t)
+
+	id, err := conn.AddCred()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]interface{}{
+		"realm":              "example.com",
+		"username":           "alice",
+		"password":           "s3cret",
+		"domain":             "example.com",
+		"eap":                "TTLS",
+		"roaming_consortium": "223344",
+		"priority":           1,
+	} {
+		if err := conn.SetCred(id, k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	vars := srv.Cred(id)
+	if vars["realm"] != `"example.com"` || vars["eap"] != "TTLS" || vars["roaming_consortium"] != "223344" || vars["priority"] != "1" {
+		t.Errorf("got %q", vars)
+	}
+
+	creds, err := conn.ListCreds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(creds) != 1 || creds[0] != (wpasupplicant.Cred{ID: id, Realm: "example.com", Username: "alice", Domain: "example.com"}) {
+		t.Errorf("got %+v", creds)
+	}
+
+	if err := conn.RemoveCred(id); err != nil {
+		t.Fatal(err)
+	}
+	if creds, err := conn.ListCreds(); err != nil || len(creds) != 0 {
+		t.Errorf("got %+v, %v", creds, err)
+	}
+}
+
+func TestInterworking(t *testing.T) {
+	srv, conn := connect(t)
+	srv.Handle("INTERWORKING_SELECT", func(args string) (string, []string) {
+		return "OK\n", []string{
+			"INTERWORKING-AP 02:00:00:00:01:00 type=home id=0 priority=1 sp_priority=0 below_min_backhaul=1",
+			"HS20-SUBSCRIPTION-REMEDIATION 1 https://osu.example.com/remediation",
+		}
+	})
+	srv.Handle("ANQP_GET", func(args string) (string, []string) {
+		return "OK\n", []string{
+			"RX-ANQP 02:00:00:00:01:00 Venue Name",
+			"RX-HS20-ANQP 02:00:00:00:01:00 WAN Metrics",
+			"ANQP-QUERY-DONE addr=02:00:00:00:01:00 result=SUCCESS",
+		}
+	})
+
+	events, unsubscribe := conn.Subscribe()
+	defer unsubscribe()
+
+	if err := conn.InterworkingSelect(false); err != nil {
+		t.Fatal(err)
+	}
+	h, ok := wpasupplicant.ParseHS20Event(waitEvent(t, events, wpasupplicant.EventInterworkingAP))
+	if !ok || h.BSSID.String() != "02:00:00:00:01:00" || h.Type != "home" || h.CredID != 0 || h.Priority != 1 || !h.BelowMinBackhaul {
+		t.Errorf("got %+v", h)
+	}
+	h, _ = wpasupplicant.ParseHS20Event(waitEvent(t, events, wpasupplicant.EventHS20SubscriptionRemediation))
+	if h.OSUMethod != 1 || h.URL != "https://osu.example.com/remediation" {
+		t.Errorf("got %+v", h)
+	}
+
+	bssid, _ := net.ParseMAC("02:00:00:00:01:00")
+	if err := conn.ANQPGet(bssid, []int{wpasupplicant.ANQPVenueName, wpasupplicant.ANQPNAIRealm}); err != nil {
+		t.Fatal(err)
+	}
+	if cmds := srv.Commands(); cmds[len(cmds)-1] != "ANQP_GET 02:00:00:00:01:00 258,263" {
+		t.Errorf("sent %q", cmds[len(cmds)-1])
+	}
+	h, _ = wpasupplicant.ParseHS20Event(waitEvent(t, events, wpasupplicant.EventRXHS20ANQP))
+	if h.Info != "WAN Metrics" {
+		t.Errorf("got %+v", h)
+	}
+	h, _ = wpasupplicant.ParseHS20Event(waitEvent(t, events, wpasupplicant.EventANQPQueryDone))
+	if !h.Success || h.BSSID.String() != "02:00:00:00:01:00" {
+		t.Errorf("got %+v", h)
+	}
+}
@@ -0,0 +1,431 @@
+// Package httpapi exposes a wpasupplicant.Conn over HTTP.
+//
+// The following endpoints are served:
+//
+//	GET    /status                 current status
+//	GET    /scan                   trigger a scan and wait for the results
+//	GET    /networks               list configured networks
+//	POST   /networks               add a network, optionally configured from the body
+//	GET    /networks/{id}          a single configured network
+//	PUT    /networks/{id}          set network variables from a JSON object
+//	DELETE /networks/{id}          remove a network
+//	POST   /networks/{id}/select   select a network
+//	GET    /events                 Server-Sent-Events stream of wpa_supplicant events
+//
+// Responses are JSON encoded.  Errors are reported as {"error": "..."} with
+// an appropriate status code.
+package httpapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-laeo/wpasupplicant"
+)
+
+// Middleware wraps a http.Handler, e.g. to authenticate requests.
+type Middleware func(http.Handler) http.Handler
+
+// Option configures a Handler.
+type Option func(h *Handler)
+
+// WithMiddleware adds middleware which is applied to every request.  The
+// first middleware given is the outermost.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(h *Handler) {
+		h.middleware = append(h.middleware, mw...)
+	}
+}
+
+// WithScanTimeout sets how long GET /scan waits for wpa_supplicant to
+// report scan results.  The default is 15 seconds.
+func WithScanTimeout(d time.Duration) Option {
+	return func(h *Handler) {
+		h.scanTimeout = d
+	}
+}
+
+// Handler is a http.Handler serving the API for a single connection.
+type Handler struct {
+	conn        wpasupplicant.Conn
+	scanTimeout time.Duration
+	middleware  []Middleware
+	handler     http.Handler
+}
+
+// NewHandler returns a Handler serving conn.
+func NewHandler(conn wpasupplicant.Conn, options ...Option) *Handler {
+	h := &Handler{
+		conn:        conn,
+		scanTimeout: 15 * time.Second,
+	}
+
+	for _, fn := range options {
+		fn(h)
+	}
+
+	var handler http.Handler = http.HandlerFunc(h.route)
+	for i := len(h.middleware) - 1; i >= 0; i-- {
+		handler = h.middleware[i](handler)
+	}
+	h.handler = handler
+
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.handler.ServeHTTP(w, r)
+}
+
+// BearerAuth returns middleware which rejects requests that don't carry
+// "Authorization: Bearer <token>".
+func BearerAuth(token string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got := []byte(r.Header.Get("Authorization"))
+			if subtle.ConstantTimeCompare(got, []byte("Bearer "+token)) != 1 {
+				writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// route dispatches a request to the handler for its path and method.
+func (h *Handler) route(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case len(parts) == 1 && parts[0] == "status":
+		h.only(w, r, http.MethodGet, h.status)
+	case len(parts) == 1 && parts[0] == "scan":
+		h.only(w, r, http.MethodGet, h.scan)
+	case len(parts) == 1 && parts[0] == "events":
+		h.only(w, r, http.MethodGet, h.events)
+	case len(parts) == 1 && parts[0] == "networks":
+		switch r.Method {
+		case http.MethodGet:
+			h.listNetworks(w, r)
+		case http.MethodPost:
+			h.addNetwork(w, r)
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodPost)
+		}
+	case len(parts) >= 2 && parts[0] == "networks":
+		id, err := strconv.Atoi(parts[1])
+		if err != nil || id < 0 {
+			writeError(w, http.StatusNotFound, fmt.Errorf("invalid network id %q", parts[1]))
+			return
+		}
+
+		switch {
+		case len(parts) == 2:
+			switch r.Method {
+			case http.MethodGet:
+				h.getNetwork(w, r, id)
+			case http.MethodPut:
+				h.putNetwork(w, r, id)
+			case http.MethodDelete:
+				h.deleteNetwork(w, r, id)
+			default:
+				methodNotAllowed(w, http.MethodGet, http.MethodPut, http.MethodDelete)
+			}
+		case len(parts) == 3 && parts[2] == "select":
+			if r.Method != http.MethodPost {
+				methodNotAllowed(w, http.MethodPost)
+				return
+			}
+			h.selectNetwork(w, r, id)
+		default:
+			http.NotFound(w, r)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// only calls fn if the request method is method.
+func (h *Handler) only(w http.ResponseWriter, r *http.Request, method string, fn http.HandlerFunc) {
+	if r.Method != method {
+		methodNotAllowed(w, method)
+		return
+	}
+	fn(w, r)
+}
+
+func (h *Handler) status(w http.ResponseWriter, r *http.Request) {
+	status, err := h.conn.Status()
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (h *Handler) scan(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.scanTimeout)
+	defer cancel()
+
+	// Subscribe before triggering the scan, so we can't miss the
+	// results event.
+	events, unsubscribe := h.conn.Subscribe()
+	defer unsubscribe()
+
+	// FAIL-BUSY means a scan is already in progress, whose results are
+	// as good as ours.
+	if err := h.conn.Scan(); err != nil && !errors.Is(err, wpasupplicant.ErrBusy) {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+wait:
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				break wait
+			}
+			switch e.Event {
+			case wpasupplicant.EventScanResults:
+				break wait
+			case wpasupplicant.EventScanFailed:
+				writeError(w, http.StatusBadGateway, errors.New("scan failed"))
+				return
+			}
+		case <-ctx.Done():
+			writeError(w, http.StatusGatewayTimeout, errors.New("timed out waiting for scan results"))
+			return
+		}
+	}
+
+	results, errs := h.conn.ScanResults()
+	if len(results) == 0 && len(errs) > 0 {
+		writeError(w, errorStatus(errs[0]), errs[0])
+		return
+	}
+	if results == nil {
+		results = []wpasupplicant.ScanResult{}
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+func (h *Handler) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming unsupported"))
+		return
+	}
+
+	events, unsubscribe := h.conn.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Event, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (h *Handler) listNetworks(w http.ResponseWriter, r *http.Request) {
+	networks, err := h.conn.ListNetworks()
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	if networks == nil {
+		networks = []wpasupplicant.ConfiguredNetwork{}
+	}
+	writeJSON(w, http.StatusOK, networks)
+}
+
+// findNetwork returns the configured network with the given id, or nil if
+// there is none.
+func (h *Handler) findNetwork(id int) (wpasupplicant.ConfiguredNetwork, error) {
+	networks, err := h.conn.ListNetworks()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, n := range networks {
+		if n.NetworkID() == strconv.Itoa(id) {
+			return n, nil
+		}
+	}
+	return nil, nil
+}
+
+func (h *Handler) getNetwork(w http.ResponseWriter, r *http.Request, id int) {
+	n, err := h.findNetwork(id)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	if n == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no network with id %d", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, n)
+}
+
+func (h *Handler) addNetwork(w http.ResponseWriter, r *http.Request) {
+	vars, err := decodeVariables(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	id, err := h.conn.AddNetwork()
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	if err := h.setVariables(id, vars); err != nil {
+		_ = h.conn.RemoveNetwork(id)
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]int{"network_id": id})
+}
+
+func (h *Handler) putNetwork(w http.ResponseWriter, r *http.Request, id int) {
+	vars, err := decodeVariables(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	n, err := h.findNetwork(id)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	if n == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no network with id %d", id))
+		return
+	}
+
+	if err := h.setVariables(id, vars); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) deleteNetwork(w http.ResponseWriter, r *http.Request, id int) {
+	if err := h.conn.RemoveNetwork(id); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) selectNetwork(w http.ResponseWriter, r *http.Request, id int) {
+	if err := h.conn.SelectNetwork(id); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeVariables decodes a JSON object of network variables from the
+// request body.  Strings are passed to SetNetwork as-is and numbers as
+// integers.  An empty body is an empty set of variables.
+func decodeVariables(r *http.Request) (map[string]interface{}, error) {
+	raw := map[string]json.RawMessage{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+			return nil, err
+		}
+	}
+
+	vars := make(map[string]interface{}, len(raw))
+	for k, v := range raw {
+		var s string
+		if err := json.Unmarshal(v, &s); err == nil {
+			vars[k] = s
+			continue
+		}
+
+		var i int
+		if err := json.Unmarshal(v, &i); err == nil {
+			vars[k] = i
+			continue
+		}
+
+		return nil, fmt.Errorf("variable %q must be a string or an integer", k)
+	}
+
+	return vars, nil
+}
+
+func (h *Handler) setVariables(id int, vars map[string]interface{}) error {
+	for k, v := range vars {
+		if err := h.conn.SetNetwork(id, k, v); err != nil {
+			return fmt.Errorf("setting %s: %w", k, err)
+		}
+	}
+	return nil
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// errorStatus returns the status code reporting err, returned by the
+// connection.  Failures without a better match are 502 Bad Gateway.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, wpasupplicant.ErrBusy):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, wpasupplicant.ErrUnknownCommand):
+		return http.StatusNotImplemented
+	case errors.Is(err, wpasupplicant.ErrPermission):
+		return http.StatusForbidden
+	case errors.Is(err, wpasupplicant.ErrTooLong):
+		return http.StatusBadRequest
+	case errors.Is(err, wpasupplicant.ErrTimeout):
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusBadGateway
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,260 @@
+package httpapi_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-laeo/wpasupplicant"
+	"github.com/go-laeo/wpasupplicant/httpapi"
+	"github.com/go-laeo/wpasupplicant/wpatest"
+)
+
+// serve starts a fake wpa_supplicant, connects to it and serves the API for
+// the connection, closing everything when the test ends.
+func serve(t *testing.T, options ...httpapi.Option) (*wpatest.Server, wpasupplicant.Conn, *httptest.Server) {
+	t.Helper()
+
+	srv, err := wpatest.NewServer("wlan0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	conn, err := wpasupplicant.ConnectPath(ctx, srv.Dir(), srv.Iface(), wpasupplicant.CommandTimeout(time.Second))
+	if err != nil {
+		cancel()
+		srv.Close()
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(httpapi.NewHandler(conn, options...))
+
+	t.Cleanup(func() {
+		ts.Close()
+		conn.Close()
+		cancel()
+		srv.Close()
+	})
+
+	return srv, conn, ts
+}
+
+// do sends a request with an optional JSON body, and decodes a JSON reply
+// into v if it isn't nil.
+func do(t *testing.T, method, url, body string, v interface{}) int {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("%s %s: %s", method, url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestNetworks(t *testing.T) {
+	srv, _, ts := serve(t)
+
+	var created map[string]int
+	if code := do(t, http.MethodPost, ts.URL+"/networks", `{"ssid": "home", "key_mgmt": "WPA-PSK", "priority": 5}`, &created); code != http.StatusCreated {
+		t.Fatalf("POST /networks: got status %d", code)
+	}
+	id := created["network_id"]
+	if n := srv.Network(id); n["ssid"] != `"home"` || n["key_mgmt"] != "WPA-PSK" || n["priority"] != "5" {
+		t.Errorf("network variables set to %v", n)
+	}
+
+	var networks []map[string]interface{}
+	if code := do(t, http.MethodGet, ts.URL+"/networks", "", &networks); code != http.StatusOK || len(networks) != 1 || networks[0]["ssid"] != "home" {
+		t.Errorf("GET /networks: got %d %v", code, networks)
+	}
+
+	if code := do(t, http.MethodPut, ts.URL+"/networks/0", `{"psk": "secret123"}`, nil); code != http.StatusNoContent {
+		t.Errorf("PUT /networks/0: got status %d", code)
+	}
+	if psk := srv.Network(id)["psk"]; psk != `"secret123"` {
+		t.Errorf("psk set to %s", psk)
+	}
+
+	if code := do(t, http.MethodPost, ts.URL+"/networks/0/select", "", nil); code != http.StatusNoContent {
+		t.Errorf("POST /networks/0/select: got status %d", code)
+	}
+
+	var errBody map[string]string
+	if code := do(t, http.MethodPut, ts.URL+"/networks/0", `{"psk": true}`, &errBody); code != http.StatusBadRequest || errBody["error"] == "" {
+		t.Errorf("PUT with an invalid variable: got %d %v", code, errBody)
+	}
+	if code := do(t, http.MethodGet, ts.URL+"/networks/7", "", nil); code != http.StatusNotFound {
+		t.Errorf("GET /networks/7: got status %d", code)
+	}
+	if code := do(t, http.MethodPatch, ts.URL+"/networks/0", "", nil); code != http.StatusMethodNotAllowed {
+		t.Errorf("PATCH /networks/0: got status %d", code)
+	}
+
+	if code := do(t, http.MethodDelete, ts.URL+"/networks/0", "", nil); code != http.StatusNoContent {
+		t.Errorf("DELETE /networks/0: got status %d", code)
+	}
+	if code := do(t, http.MethodGet, ts.URL+"/networks/0", "", nil); code != http.StatusNotFound {
+		t.Errorf("GET of a removed network: got status %d", code)
+	}
+}
+
+func TestStatusAndScan(t *testing.T) {
+	srv, _, ts := serve(t)
+	srv.SetBSSs(wpatest.BSS{BSSID: "02:00:00:00:01:00", SSID: "home", Frequency: 2412, Signal: -40, Flags: "[WPA2-PSK-CCMP][ESS]"})
+
+	var status map[string]string
+	if code := do(t, http.MethodGet, ts.URL+"/status", "", &status); code != http.StatusOK || status["wpa_state"] != "DISCONNECTED" {
+		t.Errorf("GET /status: got %d %v", code, status)
+	}
+
+	var results []map[string]interface{}
+	if code := do(t, http.MethodGet, ts.URL+"/scan", "", &results); code != http.StatusOK || len(results) != 1 || results[0]["bssid"] != "02:00:00:00:01:00" {
+		t.Errorf("GET /scan: got %d %v", code, results)
+	}
+
+	srv.Inject("STATUS", wpatest.Fault{Reply: "FAIL\n"})
+	if code := do(t, http.MethodGet, ts.URL+"/status", "", nil); code != http.StatusBadGateway {
+		t.Errorf("GET /status with a failure: got status %d", code)
+	}
+}
+
+func TestScanErrors(t *testing.T) {
+	srv, _, ts := serve(t)
+	srv.SetBSSs(wpatest.BSS{BSSID: "02:00:00:00:01:00", SSID: "home", Frequency: 2412, Signal: -40, Flags: "[ESS]"})
+
+	// A scan already in progress delivers the results.
+	srv.Handle("SCAN", func(string) (string, []string) {
+		return "FAIL-BUSY\n", []string{"CTRL-EVENT-SCAN-RESULTS "}
+	})
+	var results []map[string]interface{}
+	if code := do(t, http.MethodGet, ts.URL+"/scan", "", &results); code != http.StatusOK || len(results) != 1 {
+		t.Errorf("GET /scan while busy: got %d %v", code, results)
+	}
+
+	srv.Handle("SCAN", func(string) (string, []string) {
+		return "OK\n", []string{"CTRL-EVENT-SCAN-FAILED ret=-16"}
+	})
+	if code := do(t, http.MethodGet, ts.URL+"/scan", "", nil); code != http.StatusBadGateway {
+		t.Errorf("GET /scan with a failed scan: got status %d", code)
+	}
+
+	for reply, expect := range map[string]int{
+		"UNKNOWN COMMAND\n": http.StatusNotImplemented,
+		"FAIL-PERMISSION\n": http.StatusForbidden,
+		"FAIL\n":            http.StatusBadGateway,
+	} {
+		reply := reply
+		srv.Handle("SCAN", func(string) (string, []string) {
+			return reply, nil
+		})
+		if code := do(t, http.MethodGet, ts.URL+"/scan", "", nil); code != expect {
+			t.Errorf("GET /scan replied %q: got status %d, expect %d", reply, code, expect)
+		}
+	}
+
+	srv.Inject("STATUS", wpatest.Fault{Reply: "FAIL-BUSY\n"})
+	if code := do(t, http.MethodGet, ts.URL+"/status", "", nil); code != http.StatusServiceUnavailable {
+		t.Errorf("GET /status while busy: got status %d", code)
+	}
+}
+
+func TestBearerAuth(t *testing.T) {
+	_, _, ts := serve(t, httpapi.WithMiddleware(httpapi.BearerAuth("secret")))
+
+	for _, test := range []struct {
+		header string
+		code   int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"Bearer secret2", http.StatusUnauthorized},
+		{"secret", http.StatusUnauthorized},
+		{"Bearer secret", http.StatusOK},
+	} {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/status", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.header != "" {
+			req.Header.Set("Authorization", test.header)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != test.code {
+			t.Errorf("%q: got status %d, expect %d", test.header, resp.StatusCode, test.code)
+		}
+	}
+}
+
+func TestEvents(t *testing.T) {
+	srv, conn, ts := serve(t)
+
+	resp, err := http.Get(ts.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("got content type %q", ct)
+	}
+
+	// The subscription is made before the headers are sent, so the event
+	// can't be missed.
+	srv.Emit("CTRL-EVENT-SCAN-STARTED ")
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		s := bufio.NewScanner(resp.Body)
+		for s.Scan() {
+			lines <- s.Text()
+		}
+	}()
+
+	expect := []string{"event: SCAN-STARTED", `data: {"event":"SCAN-STARTED","line":"CTRL-EVENT-SCAN-STARTED "}`, ""}
+	for _, want := range expect {
+		select {
+		case got := <-lines:
+			if got != want {
+				t.Errorf("got %q, expect %q", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %q", want)
+		}
+	}
+
+	// Closing the connection ends the stream.
+	conn.Close()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case _, ok := <-lines:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("stream didn't end when the connection was closed")
+		}
+	}
+}
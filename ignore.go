@@ -0,0 +1,307 @@
+package wpasupplicant
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Connection failure event names.
+const (
+	EventAssocReject = "ASSOC-REJECT"
+	EventAuthReject  = "AUTH-REJECT"
+)
+
+// legacyIgnoreVariables maps the network variables of the BSSID ignore
+// list to their names before wpa_supplicant 2.10.
+var legacyIgnoreVariables = map[string]string{
+	"bssid_ignore": "bssid_blacklist",
+	"bssid_accept": "bssid_whitelist",
+}
+
+// bssidIgnoreCmd returns the name of the BSSID ignore list command:
+// BSSID_IGNORE, or BLACKLIST before wpa_supplicant 2.10.  The first call
+// finds out which one wpa_supplicant knows.
+func (uc *unixgram) bssidIgnoreCmd() (string, error) {
+	uc.ignoreLock.Lock()
+	defer uc.ignoreLock.Unlock()
+
+	if uc.ignoreCmd != "" {
+		return uc.ignoreCmd, nil
+	}
+
+	_, err := uc.cmd("BSSID_IGNORE")
+	switch {
+	case errors.Is(err, ErrUnknownCommand):
+		uc.ignoreCmd = "BLACKLIST"
+	case err != nil:
+		return "", err
+	default:
+		uc.ignoreCmd = "BSSID_IGNORE"
+	}
+	return uc.ignoreCmd, nil
+}
+
+func (uc *unixgram) IgnoreBSSID(bssid net.HardwareAddr) error {
+	cmd, err := uc.bssidIgnoreCmd()
+	if err != nil {
+		return err
+	}
+	return uc.runCommand(cmd + " " + bssid.String())
+}
+
+func (uc *unixgram) IgnoredBSSIDs() ([]net.HardwareAddr, error) {
+	cmd, err := uc.bssidIgnoreCmd()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := uc.cmd(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	// One BSSID per line.
+	var res []net.HardwareAddr
+	for _, ln := range strings.Split(string(resp), "\n") {
+		if ln == "" {
+			continue
+		}
+		bssid, err := net.ParseMAC(ln)
+		if err != nil {
+			return nil, &ParseError{Line: ln, Err: err}
+		}
+		res = append(res, bssid)
+	}
+	return res, nil
+}
+
+func (uc *unixgram) ClearIgnoredBSSIDs() error {
+	cmd, err := uc.bssidIgnoreCmd()
+	if err != nil {
+		return err
+	}
+	return uc.runCommand(cmd + " clear")
+}
+
+// IgnoreConfig configures an IgnorePolicy.  Zero fields take the default
+// values.
+type IgnoreConfig struct {
+	// Failures is the number of consecutive connection failures after
+	// which a BSSID is ignored.  The default is 3.
+	Failures int
+
+	// Expiry is how long a BSSID is ignored.  The default is 5 minutes.
+	Expiry time.Duration
+}
+
+// IgnoredBSSID is a BSSID ignored by an IgnorePolicy.  Until is when the
+// entry expires; it stays in the ignore list until every entry of the
+// policy expired.
+type IgnoredBSSID struct {
+	BSSID net.HardwareAddr
+	Until time.Time
+}
+
+// IgnorePolicy adds access points to the BSSID ignore list after repeated
+// association or authentication rejections (EventAssocReject and
+// EventAuthReject), and removes them again when they expire.  A successful
+// connection resets the failure count of a BSSID.
+//
+// wpa_supplicant can only clear the ignore list as a whole, so the policy
+// takes it over: once all of its entries expired, it clears the list and
+// adds back the BSSIDs it didn't ignore itself.  Those lose the failure
+// counts wpa_supplicant kept for them, and aren't ignored for the short
+// time between the two.
+type IgnorePolicy struct {
+	conn   Conn
+	config IgnoreConfig
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	lock     sync.Mutex
+	failures map[string]int
+	ignored  map[string]time.Time
+}
+
+// NewIgnorePolicy starts applying the policy to conn, until ctx is done or
+// Close is called.
+func NewIgnorePolicy(ctx context.Context, conn Conn, config IgnoreConfig) *IgnorePolicy {
+	if config.Failures == 0 {
+		config.Failures = 3
+	}
+	if config.Expiry == 0 {
+		config.Expiry = 5 * time.Minute
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	p := &IgnorePolicy{
+		conn:     conn,
+		config:   config,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		failures: make(map[string]int),
+		ignored:  make(map[string]time.Time),
+	}
+
+	events, unsubscribe := conn.Subscribe()
+	go p.run(ctx, events, unsubscribe)
+
+	return p
+}
+
+// Close stops applying the policy.  BSSIDs already ignored stay in the
+// ignore list.
+func (p *IgnorePolicy) Close() error {
+	p.cancel()
+	<-p.done
+	return nil
+}
+
+// Ignored returns the BSSIDs currently ignored by the policy.
+func (p *IgnorePolicy) Ignored() []IgnoredBSSID {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	res := make([]IgnoredBSSID, 0, len(p.ignored))
+	for key, until := range p.ignored {
+		bssid, _ := net.ParseMAC(key)
+		res = append(res, IgnoredBSSID{BSSID: bssid, Until: until})
+	}
+	return res
+}
+
+// run applies events and expiries until ctx is done.
+func (p *IgnorePolicy) run(ctx context.Context, events <-chan WPAEvent, unsubscribe func()) {
+	defer close(p.done)
+	defer unsubscribe()
+
+	expiry := time.NewTimer(0)
+	defer expiry.Stop()
+	<-expiry.C
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			p.handle(e)
+		case <-expiry.C:
+			p.expire()
+		}
+
+		if next, ok := p.clearTime(); ok {
+			if !expiry.Stop() {
+				select {
+				case <-expiry.C:
+				default:
+				}
+			}
+			expiry.Reset(time.Until(next))
+		}
+	}
+}
+
+// handle counts failures and successes.
+func (p *IgnorePolicy) handle(e WPAEvent) {
+	var bssid string
+	failed := true
+	switch e.Event {
+	case EventAssocReject:
+		// CTRL-EVENT-ASSOC-REJECT bssid=<bssid> status_code=17
+		bssid = e.Arguments["bssid"]
+	case EventAuthReject:
+		// CTRL-EVENT-AUTH-REJECT <bssid> auth_type=0 ...
+		if f := splitEventFields(e.Line); len(f) > 1 {
+			bssid = f[1]
+		}
+	case EventConnected:
+		// CTRL-EVENT-CONNECTED - Connection to <bssid> completed ...
+		if f := splitEventFields(e.Line); len(f) > 4 {
+			bssid = f[4]
+		}
+		failed = false
+	}
+
+	// The BSSID is all zeros when wpa_supplicant doesn't know the AP.
+	addr, err := net.ParseMAC(bssid)
+	if err != nil || bytes.Equal(addr, make(net.HardwareAddr, len(addr))) {
+		return
+	}
+	key := addr.String()
+
+	p.lock.Lock()
+	if !failed {
+		delete(p.failures, key)
+		p.lock.Unlock()
+		return
+	}
+	p.failures[key]++
+	ignore := p.failures[key] >= p.config.Failures
+	if ignore {
+		delete(p.failures, key)
+		p.ignored[key] = time.Now().Add(p.config.Expiry)
+	}
+	p.lock.Unlock()
+
+	if ignore {
+		_ = p.conn.IgnoreBSSID(addr)
+	}
+}
+
+// expire clears the ignore list once every BSSID the policy ignored
+// expired, adding back the BSSIDs the policy didn't ignore.  Clearing it
+// earlier would let wpa_supplicant join the BSSIDs which are still
+// ignored.
+func (p *IgnorePolicy) expire() {
+	now := time.Now()
+
+	p.lock.Lock()
+	for _, until := range p.ignored {
+		if now.Before(until) {
+			p.lock.Unlock()
+			return
+		}
+	}
+	expired := p.ignored
+	p.ignored = make(map[string]time.Time)
+	p.lock.Unlock()
+
+	if len(expired) == 0 {
+		return
+	}
+
+	list, err := p.conn.IgnoredBSSIDs()
+	if err != nil {
+		return
+	}
+	if err := p.conn.ClearIgnoredBSSIDs(); err != nil {
+		return
+	}
+	for _, bssid := range list {
+		if _, ok := expired[bssid.String()]; !ok {
+			_ = p.conn.IgnoreBSSID(bssid)
+		}
+	}
+}
+
+// clearTime returns when the ignore list is due to be cleared, i.e. when
+// the last BSSID expires, or false if none is ignored.
+func (p *IgnorePolicy) clearTime() (time.Time, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var last time.Time
+	for _, until := range p.ignored {
+		if until.After(last) {
+			last = until
+		}
+	}
+	return last, !last.IsZero()
+}
@@ -0,0 +1,141 @@
+package wpasupplicant_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-laeo/wpasupplicant"
+)
+
+func TestIgnoreBSSID(t *testing.T) {
+	for _, legacy := range []bool{false, true} {
+		srv, conn := connect(t)
+		cmd := "BSSID_IGNORE"
+		ignoreVar, acceptVar := "bssid_ignore", "bssid_accept"
+		if legacy {
+			srv.Handle("BSSID_IGNORE", func(string) (string, []string) {
+				return "UNKNOWN COMMAND\n", nil
+			})
+			cmd = "BLACKLIST"
+			ignoreVar, acceptVar = "bssid_blacklist", "bssid_whitelist"
+		}
+
+		bad, _ := net.ParseMAC("02:00:00:00:01:00")
+		good, _ := net.ParseMAC("02:00:00:00:02:00")
+		if err := conn.IgnoreBSSID(bad); err != nil {
+			t.Fatal(err)
+		}
+		if cmds := srv.Commands(); cmds[len(cmds)-1] != cmd+" 02:00:00:00:01:00" {
+			t.Errorf("sent %q", cmds[len(cmds)-1])
+		}
+		if list, err := conn.IgnoredBSSIDs(); err != nil || len(list) != 1 || list[0].String() != bad.String() {
+			t.Errorf("got %v, %v", list, err)
+		}
+		if err := conn.ClearIgnoredBSSIDs(); err != nil {
+			t.Fatal(err)
+		}
+		if list, err := conn.IgnoredBSSIDs(); err != nil || len(list) != 0 {
+			t.Errorf("got %v, %v", list, err)
+		}
+
+		id, err := conn.CreateNetwork(wpasupplicant.NetworkConfig{
+			SSID:        "office",
+			BSSIDIgnore: []net.HardwareAddr{bad},
+			BSSIDAccept: []net.HardwareAddr{good, bad},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		vars := srv.Network(id)
+		if vars[ignoreVar] != "02:00:00:00:01:00" || vars[acceptVar] != "02:00:00:00:02:00 02:00:00:00:01:00" {
+			t.Errorf("legacy %v: got %q", legacy, vars)
+		}
+	}
+}
+
+func TestIgnorePolicy(t *testing.T) {
+	srv, conn := connect(t)
+
+	policy := wpasupplicant.NewIgnorePolicy(context.Background(), conn, wpasupplicant.IgnoreConfig{Failures: 2, Expiry: 200 * time.Millisecond})
+	defer policy.Close()
+
+	other, _ := net.ParseMAC("02:00:00:00:09:00")
+	if err := conn.IgnoreBSSID(other); err != nil {
+		t.Fatal(err)
+	}
+
+	// A success resets the count.
+	srv.Emit("CTRL-EVENT-ASSOC-REJECT bssid=02:00:00:00:01:00 status_code=17")
+	srv.Emit("CTRL-EVENT-CONNECTED - Connection to 02:00:00:00:01:00 completed [id=0 id_str=]")
+	srv.Emit("CTRL-EVENT-AUTH-REJECT 02:00:00:00:01:00 auth_type=0 auth_transaction=2 status_code=1")
+
+	// Rejections by unknown APs aren't counted.
+	srv.Emit("CTRL-EVENT-ASSOC-REJECT bssid=00:00:00:00:00:00 status_code=1")
+	srv.Emit("CTRL-EVENT-ASSOC-REJECT bssid=00:00:00:00:00:00 status_code=1")
+	time.Sleep(50 * time.Millisecond)
+	if ignored := policy.Ignored(); len(ignored) != 0 {
+		t.Fatalf("got %+v", ignored)
+	}
+
+	srv.Emit("CTRL-EVENT-ASSOC-REJECT bssid=02:00:00:00:01:00 status_code=17")
+	deadline := time.Now().Add(time.Second)
+	for len(policy.Ignored()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	ignored := policy.Ignored()
+	if len(ignored) != 1 || ignored[0].BSSID.String() != "02:00:00:00:01:00" {
+		t.Fatalf("got %+v", ignored)
+	}
+	if list, err := conn.IgnoredBSSIDs(); err != nil || len(list) != 2 {
+		t.Errorf("got %v, %v", list, err)
+	}
+
+	// On expiry, the entries the policy didn't add are kept.
+	time.Sleep(time.Until(ignored[0].Until) + 100*time.Millisecond)
+	if ignored := policy.Ignored(); len(ignored) != 0 {
+		t.Errorf("got %+v", ignored)
+	}
+	if list, err := conn.IgnoredBSSIDs(); err != nil || len(list) != 1 || list[0].String() != other.String() {
+		t.Errorf("got %v, %v", list, err)
+	}
+}
+
+func TestIgnorePolicyPostponesClear(t *testing.T) {
+	srv, conn := connect(t)
+
+	policy := wpasupplicant.NewIgnorePolicy(context.Background(), conn, wpasupplicant.IgnoreConfig{Failures: 1, Expiry: 300 * time.Millisecond})
+	defer policy.Close()
+
+	waitIgnored := func(n int) []wpasupplicant.IgnoredBSSID {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for len(policy.Ignored()) != n && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		ignored := policy.Ignored()
+		if len(ignored) != n {
+			t.Fatalf("got %+v, expect %d entries", ignored, n)
+		}
+		return ignored
+	}
+
+	srv.Emit("CTRL-EVENT-ASSOC-REJECT bssid=02:00:00:00:01:00 status_code=17")
+	first := waitIgnored(1)[0]
+	time.Sleep(150 * time.Millisecond)
+	srv.Emit("CTRL-EVENT-ASSOC-REJECT bssid=02:00:00:00:02:00 status_code=17")
+	waitIgnored(2)
+
+	// The first entry expired, but clearing the list would also remove
+	// the second one.
+	time.Sleep(time.Until(first.Until) + 30*time.Millisecond)
+	if list, err := conn.IgnoredBSSIDs(); err != nil || len(list) != 2 {
+		t.Errorf("got %v, %v", list, err)
+	}
+
+	waitIgnored(0)
+	if list, err := conn.IgnoredBSSIDs(); err != nil || len(list) != 0 {
+		t.Errorf("got %v, %v", list, err)
+	}
+}
@@ -0,0 +1,100 @@
+package wpasupplicant
+
+import (
+	"net"
+	"strings"
+)
+
+// ManualMode disables wpa_supplicant's automatic connection
+// (STA_AUTOCONNECT 0) for as long as the connection is open, so that it
+// only connects to the networks the application selects.  See
+// SetManualMode.  It has no effect on ConnectGlobal, as automatic connection
+// is a setting of each interface: use SetManualMode on the views returned
+// by GlobalConn.Interface, which leave manual mode when the global
+// connection is closed.
+func ManualMode() Option {
+	return func(conn *unixgram) error {
+		conn.manualOnDial = true
+		return nil
+	}
+}
+
+func (uc *unixgram) Disconnect() error {
+	return uc.runCommand("DISCONNECT")
+}
+
+func (uc *unixgram) Reauthenticate() error {
+	return uc.runCommand("REAUTHENTICATE")
+}
+
+func (uc *unixgram) Preauth(bssid net.HardwareAddr) error {
+	return uc.runCommand("PREAUTH " + bssid.String())
+}
+
+func (uc *unixgram) Logon() error {
+	return uc.runCommand("LOGON")
+}
+
+func (uc *unixgram) Logoff() error {
+	return uc.runCommand("LOGOFF")
+}
+
+func (uc *unixgram) SetAutoconnect(enabled bool) error {
+	if enabled {
+		return uc.runCommand("STA_AUTOCONNECT 1")
+	}
+	return uc.runCommand("STA_AUTOCONNECT 0")
+}
+
+func (uc *unixgram) SetManualMode(manual bool) error {
+	uc.manualLock.Lock()
+	defer uc.manualLock.Unlock()
+
+	if err := uc.SetAutoconnect(!manual); err != nil {
+		return err
+	}
+	uc.manual = manual
+	return nil
+}
+
+// restoreAutoconnect leaves manual mode, if enabled, when the connection is
+// closed.
+func (uc *unixgram) restoreAutoconnect() error {
+	uc.manualLock.Lock()
+	manual := uc.manual
+	uc.manualLock.Unlock()
+
+	if !manual {
+		return nil
+	}
+	return uc.SetManualMode(false)
+}
+
+func (uc *unixgram) AbortScan() error {
+	return uc.runCommand("ABORT_SCAN")
+}
+
+func (uc *unixgram) Flush() error {
+	return uc.runCommand("FLUSH")
+}
+
+func (uc *unixgram) Suspend() error {
+	return uc.runCommand("SUSPEND")
+}
+
+func (uc *unixgram) Resume() error {
+	return uc.runCommand("RESUME")
+}
+
+func (uc *unixgram) Terminate() error {
+	return uc.runCommand("TERMINATE")
+}
+
+func (uc *unixgram) Driver(cmd string) (string, error) {
+	resp, err := uc.cmd("DRIVER " + cmd)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(string(resp), "\n"), nil
+}
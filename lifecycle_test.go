@@ -0,0 +1,139 @@
+package wpasupplicant_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-laeo/wpasupplicant"
+	"github.com/go-laeo/wpasupplicant/wpatest"
+)
+
+func TestManualMode(t *testing.T) {
+	srv, conn := connect(t, wpasupplicant.ManualMode())
+	if srv.Autoconnect() {
+		t.Error("autoconnect enabled in manual mode")
+	}
+
+	if err := conn.SetManualMode(false); err != nil {
+		t.Fatal(err)
+	}
+	if !srv.Autoconnect() {
+		t.Error("autoconnect disabled after leaving manual mode")
+	}
+
+	if err := conn.SetManualMode(true); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if !srv.Autoconnect() {
+		t.Error("autoconnect not restored on close")
+	}
+}
+
+func TestManualModeGlobal(t *testing.T) {
+	srv, err := wpatest.NewServer("global")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	srv.Handle("STA_AUTOCONNECT", func(string) (string, []string) {
+		return "UNKNOWN COMMAND\n", nil
+	})
+	srv.Handle("IFNAME=wlan1", func(string) (string, []string) {
+		return "OK\n", nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The global socket has no autoconnect setting.
+	g, err := wpasupplicant.ConnectGlobal(ctx, srv.Path(), wpasupplicant.CommandTimeout(time.Second), wpasupplicant.ManualMode())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wlan1 := g.Interface("wlan1")
+	if err := wlan1.SetManualMode(true); err != nil {
+		t.Fatal(err)
+	}
+	waitCommand(t, srv, "IFNAME=wlan1 STA_AUTOCONNECT 0")
+
+	// Closing the global connection restores autoconnect on its views.
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	waitCommand(t, srv, "IFNAME=wlan1 STA_AUTOCONNECT 1")
+
+	for _, c := range srv.Commands() {
+		if c == "STA_AUTOCONNECT 0" {
+			t.Error("STA_AUTOCONNECT sent to the global socket")
+		}
+	}
+}
+
+func TestLifecycleCommands(t *testing.T) {
+	srv, conn := connect(t)
+	srv.SetBSSs(wpatest.BSS{BSSID: "02:00:00:00:01:00", SSID: "home", Frequency: 2412, Signal: -40, Flags: "[ESS]"})
+
+	id, err := conn.CreateNetwork(wpasupplicant.NetworkConfig{SSID: "home"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.SelectNetwork(id); err != nil {
+		t.Fatal(err)
+	}
+
+	events, unsubscribe := conn.Subscribe()
+	defer unsubscribe()
+
+	if err := conn.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, events, "DISCONNECTED")
+
+	bssid, _ := net.ParseMAC("02:00:00:00:01:00")
+	for _, fn := range []func() error{
+		conn.Reauthenticate,
+		func() error { return conn.Preauth(bssid) },
+		conn.Logon,
+		conn.Logoff,
+		conn.Suspend,
+		conn.Resume,
+	} {
+		if err := fn(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if err := conn.AbortScan(); err == nil {
+		t.Error("expected ABORT_SCAN to fail without a scan")
+	}
+
+	srv.Handle("DRIVER", func(args string) (string, []string) {
+		if args == "MACADDR" {
+			return "Macaddr = 02:00:00:00:00:01\n", nil
+		}
+		return "FAIL\n", nil
+	})
+	if reply, err := conn.Driver("MACADDR"); err != nil || reply != "Macaddr = 02:00:00:00:00:01" {
+		t.Errorf("got %q, %v", reply, err)
+	}
+	if _, err := conn.Driver("BTCOEXMODE 1"); err == nil {
+		t.Error("expected the driver command to fail")
+	}
+
+	if err := conn.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if networks, err := conn.ListNetworks(); err != nil || len(networks) != 0 {
+		t.Errorf("got %v, %v", networks, err)
+	}
+
+	if err := conn.Terminate(); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, events, "TERMINATING")
+}
@@ -0,0 +1,248 @@
+package wpasupplicant
+
+import (
+	"context"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Events generated by a Manager when an interface's control socket appears
+// or disappears.  WPAEvent.Interface is the interface concerned.
+const (
+	EventInterfaceAdded   = "INTERFACE-ADDED"
+	EventInterfaceRemoved = "INTERFACE-REMOVED"
+)
+
+// socketChange is a control socket appearing or disappearing in the watched
+// directory.
+type socketChange struct {
+	name    string
+	created bool
+}
+
+// Manager watches a control directory (/run/wpa_supplicant by default) for
+// per-interface sockets, keeps a connection open to each interface and
+// merges their events.
+type Manager struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	ctrlPath string
+	options  []Option
+	done     chan struct{}
+
+	lock  sync.Mutex
+	conns map[string]*managedConn
+
+	subLock     sync.Mutex
+	subscribers map[chan WPAEvent]struct{}
+}
+
+// managedConn is a connection opened by a Manager.
+type managedConn struct {
+	conn        Conn
+	unsubscribe func()
+}
+
+// connectRetries is how often a Manager tries to connect to a new socket,
+// since wpa_supplicant may not be ready to answer as soon as it appears.
+const connectRetries = 5
+
+// NewManager starts watching ctrlPath, or /run/wpa_supplicant if it is
+// empty, and connects to every interface found there.  The options are
+// used for each connection.  The directory must exist.
+func NewManager(ctx context.Context, ctrlPath string, options ...Option) (*Manager, error) {
+	if ctrlPath == "" {
+		ctrlPath = stdSocketPath
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	m := &Manager{
+		ctx:         ctx,
+		cancel:      cancel,
+		ctrlPath:    ctrlPath,
+		options:     options,
+		done:        make(chan struct{}),
+		conns:       make(map[string]*managedConn),
+		subscribers: make(map[chan WPAEvent]struct{}),
+	}
+
+	// Start watching before listing, so that no socket is missed.
+	changes := make(chan socketChange, 16)
+	if err := watchSockets(ctx, ctrlPath, changes); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(ctrlPath)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.Type()&os.ModeSocket != 0 {
+			m.add(e.Name())
+		}
+	}
+
+	go m.run(changes)
+
+	return m, nil
+}
+
+// run applies socket changes until the manager is closed.
+func (m *Manager) run(changes <-chan socketChange) {
+	defer close(m.done)
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case c := <-changes:
+			if c.created {
+				m.add(c.name)
+			} else {
+				m.remove(c.name)
+			}
+		}
+	}
+}
+
+// add connects to a new interface.
+func (m *Manager) add(iface string) {
+	m.lock.Lock()
+	_, ok := m.conns[iface]
+	m.lock.Unlock()
+	if ok {
+		return
+	}
+
+	var conn Conn
+	var err error
+	for i := 0; i < connectRetries; i++ {
+		if conn, err = ConnectPath(m.ctx, m.ctrlPath, iface, m.options...); err == nil {
+			break
+		}
+
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	if err != nil {
+		return
+	}
+
+	events, unsubscribe := conn.Subscribe()
+	m.lock.Lock()
+	m.conns[iface] = &managedConn{conn: conn, unsubscribe: unsubscribe}
+	m.lock.Unlock()
+
+	go func() {
+		for e := range events {
+			e.Interface = iface
+			m.publish(e)
+		}
+	}()
+
+	m.publish(WPAEvent{Event: EventInterfaceAdded, Interface: iface})
+}
+
+// remove closes the connection to an interface which disappeared.
+func (m *Manager) remove(iface string) {
+	m.lock.Lock()
+	mc, ok := m.conns[iface]
+	delete(m.conns, iface)
+	m.lock.Unlock()
+	if !ok {
+		return
+	}
+
+	mc.unsubscribe()
+	mc.conn.Close()
+
+	m.publish(WPAEvent{Event: EventInterfaceRemoved, Interface: iface})
+}
+
+// publish delivers an event to every subscriber without blocking.
+func (m *Manager) publish(e WPAEvent) {
+	m.subLock.Lock()
+	defer m.subLock.Unlock()
+
+	for c := range m.subscribers {
+		select {
+		case c <- e:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel which receives the events of every managed
+// interface, with WPAEvent.Interface set, as well as EventInterfaceAdded
+// and EventInterfaceRemoved.  The returned function cancels the
+// subscription and closes the channel.  Events are dropped for subscribers
+// which don't keep up.
+func (m *Manager) Subscribe() (<-chan WPAEvent, func()) {
+	c := make(chan WPAEvent, subscriberQueueLen)
+
+	m.subLock.Lock()
+	m.subscribers[c] = struct{}{}
+	m.subLock.Unlock()
+
+	var once sync.Once
+	return c, func() {
+		once.Do(func() {
+			m.subLock.Lock()
+			delete(m.subscribers, c)
+			close(c)
+			m.subLock.Unlock()
+		})
+	}
+}
+
+// Interfaces returns the names of the interfaces currently connected.
+func (m *Manager) Interfaces() []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	ifaces := make([]string, 0, len(m.conns))
+	for iface := range m.conns {
+		ifaces = append(ifaces, iface)
+	}
+	sort.Strings(ifaces)
+	return ifaces
+}
+
+// Conn returns the connection to iface, or nil if there is none.  It must
+// not be closed by the caller.
+func (m *Manager) Conn(iface string) Conn {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if mc, ok := m.conns[iface]; ok {
+		return mc.conn
+	}
+	return nil
+}
+
+// Close stops watching and closes every connection.
+func (m *Manager) Close() error {
+	m.cancel()
+	<-m.done
+
+	m.lock.Lock()
+	conns := m.conns
+	m.conns = make(map[string]*managedConn)
+	m.lock.Unlock()
+
+	var err error
+	for _, mc := range conns {
+		mc.unsubscribe()
+		if cerr := mc.conn.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package wpasupplicant
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+// watchSockets reports sockets created in or removed from dir to changes,
+// using inotify, until ctx is done.
+func watchSockets(ctx context.Context, dir string, changes chan<- socketChange) error {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return os.NewSyscallError("inotify_init1", err)
+	}
+
+	mask := uint32(syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MOVED_TO | syscall.IN_MOVED_FROM)
+	if _, err := syscall.InotifyAddWatch(fd, dir, mask); err != nil {
+		syscall.Close(fd)
+		return &os.PathError{Op: "inotify_add_watch", Path: dir, Err: err}
+	}
+
+	// The descriptor is non-blocking, so reads go through the runtime
+	// poller and are interrupted by closing the file.
+	f := os.NewFile(uintptr(fd), "inotify")
+
+	go func() {
+		<-ctx.Done()
+		f.Close()
+	}()
+
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := f.Read(buf)
+			if err != nil {
+				return
+			}
+
+			for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+				ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+				nameLen := int(ev.Len)
+				name := buf[off+syscall.SizeofInotifyEvent : off+syscall.SizeofInotifyEvent+nameLen]
+				off += syscall.SizeofInotifyEvent + nameLen
+
+				if i := bytes.IndexByte(name, 0); i != -1 {
+					name = name[:i]
+				}
+				if len(name) == 0 {
+					continue
+				}
+
+				c := socketChange{
+					name:    string(name),
+					created: ev.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0,
+				}
+				if c.created {
+					// Only sockets are interesting.
+					fi, err := os.Lstat(filepath.Join(dir, c.name))
+					if err != nil || fi.Mode()&os.ModeSocket == 0 {
+						continue
+					}
+				}
+
+				select {
+				case changes <- c:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return nil
+}
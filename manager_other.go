@@ -0,0 +1,80 @@
+//go:build !linux
+// +build !linux
+
+package wpasupplicant
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// pollInterval is how often the control directory is listed on platforms
+// without inotify.
+const pollInterval = 2 * time.Second
+
+// watchSockets reports sockets created in or removed from dir to changes,
+// by listing it periodically, until ctx is done.
+func watchSockets(ctx context.Context, dir string, changes chan<- socketChange) error {
+	seen, err := listSockets(dir)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		t := time.NewTicker(pollInterval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+
+			current, err := listSockets(dir)
+			if err != nil {
+				continue
+			}
+
+			var diff []socketChange
+			for name := range current {
+				if !seen[name] {
+					diff = append(diff, socketChange{name: name, created: true})
+				}
+			}
+			for name := range seen {
+				if !current[name] {
+					diff = append(diff, socketChange{name: name})
+				}
+			}
+			seen = current
+
+			for _, c := range diff {
+				select {
+				case changes <- c:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// listSockets returns the names of the sockets in dir.
+func listSockets(dir string) (map[string]bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool)
+	for _, e := range entries {
+		if e.Type()&os.ModeSocket != 0 {
+			names[e.Name()] = true
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,64 @@
+package wpasupplicant_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-laeo/wpasupplicant"
+	"github.com/go-laeo/wpasupplicant/wpatest"
+)
+
+func TestManager(t *testing.T) {
+	dir, err := os.MkdirTemp("", "wpatest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	wlan0, err := wpatest.NewServerDir(dir, "wlan0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wlan0.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m, err := wpasupplicant.NewManager(ctx, dir, wpasupplicant.CommandTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if ifaces := m.Interfaces(); len(ifaces) != 1 || ifaces[0] != "wlan0" {
+		t.Fatalf("got interfaces %q", ifaces)
+	}
+
+	events, unsubscribe := m.Subscribe()
+	defer unsubscribe()
+
+	wlan1, err := wpatest.NewServerDir(dir, "wlan1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := waitEvent(t, events, wpasupplicant.EventInterfaceAdded); e.Interface != "wlan1" {
+		t.Errorf("added %q", e.Interface)
+	}
+	if err := m.Conn("wlan1").Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	wlan1.Emit("CTRL-EVENT-SCAN-RESULTS ")
+	if e := waitEvent(t, events, "SCAN-RESULTS"); e.Interface != "wlan1" {
+		t.Errorf("event tagged with %q", e.Interface)
+	}
+
+	wlan1.Close()
+	if e := waitEvent(t, events, wpasupplicant.EventInterfaceRemoved); e.Interface != "wlan1" {
+		t.Errorf("removed %q", e.Interface)
+	}
+	if m.Conn("wlan1") != nil {
+		t.Error("connection to removed interface still open")
+	}
+}
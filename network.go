@@ -0,0 +1,244 @@
+package wpasupplicant
+
+import (
+	"encoding/hex"
+	"errors"
+	"net"
+	"strings"
+)
+
+// PMF is the management frame protection (ieee80211w) setting of a
+// network.
+type PMF int
+
+const (
+	// PMFDefault uses wpa_supplicant's global pmf setting.
+	PMFDefault PMF = iota
+	PMFDisabled
+	PMFOptional
+	PMFRequired
+)
+
+// eapKeyMgmt are the key management types which authenticate with EAP.
+const eapKeyMgmt = IEEE8021X | IEEE8021X_NO_WPA | FT_IEEE8021X | IEEE8021X_SHA256 |
+	IEEE8021X_SUITE_B | IEEE8021X_SUITE_B_192 | FT_IEEE8021X_SHA384 |
+	FILS_SHA256 | FILS_SHA384 | FT_FILS_SHA256 | FT_FILS_SHA384
+
+// pskKeyMgmt are the key management types which use a pre-shared key.
+const pskKeyMgmt = PSK | FT_PSK | PSK_SHA256
+
+// saeKeyMgmt are the key management types which use SAE.
+const saeKeyMgmt = SAE | FT_SAE
+
+// NetworkConfig describes a client network for CreateNetwork.  Only SSID
+// is required.
+type NetworkConfig struct {
+	SSID string
+
+	// BSSID restricts the network to one access point.
+	BSSID net.HardwareAddr
+
+	// BSSIDIgnore are access points never to connect to, and BSSIDAccept,
+	// if set, the only ones to connect to.
+	BSSIDIgnore []net.HardwareAddr
+	BSSIDAccept []net.HardwareAddr
+
+	// ScanSSID probes for the SSID, which is needed to find hidden
+	// networks.
+	ScanSSID bool
+
+	// KeyMgmt defaults to WPA-EAP if EAP is set, WPA-PSK if PSK is set,
+	// SAE if SAEPassword is set, or NONE.
+	KeyMgmt KeyMgmt
+
+	// Pairwise and Group restrict the ciphers used.  Zero allows
+	// wpa_supplicant's defaults.
+	Pairwise Cipher
+	Group    Cipher
+
+	// PSK is the WPA passphrase, 8 to 63 characters, or the 256 bit key
+	// as 64 hex digits.
+	PSK string
+
+	// SAEPassword is the WPA3 password, if different from PSK.
+	SAEPassword string
+
+	PMF PMF
+
+	EAP *EAPConfig
+
+	// Priority is the selection priority; higher is preferred.
+	Priority int
+
+	// Disabled adds the network disabled.
+	Disabled bool
+}
+
+// Validate checks that the config is consistent.
+func (c NetworkConfig) Validate() error {
+	_, err := c.variables()
+	return err
+}
+
+// keyMgmt returns the key management, with the default.
+func (c NetworkConfig) keyMgmt() KeyMgmt {
+	switch {
+	case c.KeyMgmt != 0:
+		return c.KeyMgmt
+	case c.EAP != nil:
+		return IEEE8021X
+	case c.PSK != "":
+		return PSK
+	case c.SAEPassword != "":
+		return SAE
+	}
+	return KEY_MGMT_NONE
+}
+
+// variables validates the config and returns the network variables which
+// configure it, in the order they must be set.
+func (c NetworkConfig) variables() ([][2]interface{}, error) {
+	if len(c.SSID) == 0 || len(c.SSID) > 32 {
+		return nil, errors.New("SSID must be 1 to 32 bytes")
+	}
+
+	keyMgmt := c.keyMgmt()
+	if keyMgmt&eapKeyMgmt != 0 && c.EAP == nil {
+		return nil, errors.New("key management " + keyMgmt.String() + " requires an EAP config")
+	}
+	if keyMgmt&eapKeyMgmt == 0 && c.EAP != nil {
+		return nil, errors.New("EAP config without EAP key management")
+	}
+	if keyMgmt&pskKeyMgmt != 0 && c.PSK == "" {
+		return nil, errors.New("key management " + keyMgmt.String() + " requires a PSK")
+	}
+	if keyMgmt&(pskKeyMgmt|saeKeyMgmt) == 0 && c.PSK != "" {
+		return nil, errors.New("PSK without PSK key management")
+	}
+	if keyMgmt&saeKeyMgmt != 0 {
+		if c.PSK == "" && c.SAEPassword == "" {
+			return nil, errors.New("SAE requires a password")
+		}
+		if c.PMF == PMFDisabled {
+			return nil, errors.New("SAE requires management frame protection")
+		}
+	}
+
+	vars := [][2]interface{}{{"ssid", []byte(c.SSID)}}
+	if c.BSSID != nil {
+		vars = append(vars, [2]interface{}{"bssid", c.BSSID.String()})
+	}
+	if len(c.BSSIDIgnore) > 0 {
+		vars = append(vars, [2]interface{}{"bssid_ignore", joinAddrs(c.BSSIDIgnore)})
+	}
+	if len(c.BSSIDAccept) > 0 {
+		vars = append(vars, [2]interface{}{"bssid_accept", joinAddrs(c.BSSIDAccept)})
+	}
+	if c.ScanSSID {
+		vars = append(vars, [2]interface{}{"scan_ssid", 1})
+	}
+	vars = append(vars, [2]interface{}{"key_mgmt", keyMgmt.String()})
+	if c.Pairwise != 0 {
+		vars = append(vars, [2]interface{}{"pairwise", c.Pairwise.String()})
+	}
+	if c.Group != 0 {
+		vars = append(vars, [2]interface{}{"group", c.Group.String()})
+	}
+
+	if c.PSK != "" {
+		if len(c.PSK) == 64 {
+			// A raw key is set unquoted, which SetNetwork does for
+			// []byte.
+			key, err := hex.DecodeString(c.PSK)
+			if err != nil {
+				return nil, errors.New("64 character PSK must be hex")
+			}
+			vars = append(vars, [2]interface{}{"psk", key})
+		} else if len(c.PSK) < 8 || len(c.PSK) > 63 {
+			return nil, errors.New("passphrase must be 8 to 63 characters")
+		} else {
+			vars = append(vars, [2]interface{}{"psk", c.PSK})
+		}
+	}
+	if c.SAEPassword != "" {
+		vars = append(vars, [2]interface{}{"sae_password", c.SAEPassword})
+	}
+	if c.PMF != PMFDefault {
+		vars = append(vars, [2]interface{}{"ieee80211w", int(c.PMF) - 1})
+	}
+
+	if c.EAP != nil {
+		if err := c.EAP.Validate(); err != nil {
+			return nil, err
+		}
+		vars = append(vars, c.EAP.variables()...)
+	}
+
+	if c.Priority != 0 {
+		vars = append(vars, [2]interface{}{"priority", c.Priority})
+	}
+	if c.Disabled {
+		vars = append(vars, [2]interface{}{"disabled", 1})
+	}
+
+	return vars, nil
+}
+
+// joinAddrs formats a list of addresses as a network variable.
+func joinAddrs(addrs []net.HardwareAddr) string {
+	s := make([]string, len(addrs))
+	for i, a := range addrs {
+		s[i] = a.String()
+	}
+	return strings.Join(s, " ")
+}
+
+func (uc *unixgram) CreateNetwork(c NetworkConfig) (int, error) {
+	vars, err := c.variables()
+	if err != nil {
+		return -1, err
+	}
+
+	uc.capsLock.Lock()
+	caps := uc.caps
+	uc.capsLock.Unlock()
+	if caps != nil {
+		if err := c.CheckCapabilities(caps); err != nil {
+			return -1, err
+		}
+	}
+
+	if len(c.BSSIDIgnore) > 0 || len(c.BSSIDAccept) > 0 {
+		cmd, err := uc.bssidIgnoreCmd()
+		if err != nil {
+			return -1, err
+		}
+		if cmd == "BLACKLIST" {
+			for i := range vars {
+				if name, ok := legacyIgnoreVariables[vars[i][0].(string)]; ok {
+					vars[i][0] = name
+				}
+			}
+		}
+	}
+
+	return uc.addNetwork(vars)
+}
+
+// addNetwork adds a network with the given variables.  The network is
+// removed again if setting a variable fails.
+func (uc *unixgram) addNetwork(vars [][2]interface{}) (int, error) {
+	id, err := uc.AddNetwork()
+	if err != nil {
+		return -1, err
+	}
+
+	for _, v := range vars {
+		if err := uc.SetNetwork(id, v[0].(string), v[1]); err != nil {
+			_ = uc.RemoveNetwork(id)
+			return -1, err
+		}
+	}
+
+	return id, nil
+}
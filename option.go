@@ -3,6 +3,7 @@ package wpasupplicant
 import (
 	"net"
 	"os"
+	"time"
 )
 
 // Option is the most commonly practice to configure the struct.
@@ -25,3 +26,12 @@ func CustomUnixgram(local, remote string) Option {
 		return err
 	}
 }
+
+// CommandTimeout limits how long a command waits for its reply, after which
+// it fails with ErrTimeout.  By default commands wait forever.
+func CommandTimeout(d time.Duration) Option {
+	return func(conn *unixgram) error {
+		conn.timeout = d
+		return nil
+	}
+}
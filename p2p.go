@@ -0,0 +1,586 @@
+package wpasupplicant
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// P2P event names.
+const (
+	EventP2PDeviceFound      = "P2P-DEVICE-FOUND"
+	EventP2PDeviceLost       = "P2P-DEVICE-LOST"
+	EventP2PGONegRequest     = "P2P-GO-NEG-REQUEST"
+	EventP2PGONegSuccess     = "P2P-GO-NEG-SUCCESS"
+	EventP2PGONegFailure     = "P2P-GO-NEG-FAILURE"
+	EventP2PGroupStarted     = "P2P-GROUP-STARTED"
+	EventP2PGroupRemoved     = "P2P-GROUP-REMOVED"
+	EventP2PInvitationResult = "P2P-INVITATION-RESULT"
+	EventP2PProvDiscShowPin  = "P2P-PROV-DISC-SHOW-PIN"
+	EventP2PProvDiscEnterPin = "P2P-PROV-DISC-ENTER-PIN"
+	EventP2PProvDiscPBCReq   = "P2P-PROV-DISC-PBC-REQ"
+	EventP2PProvDiscPBCResp  = "P2P-PROV-DISC-PBC-RESP"
+	EventP2PProvDiscFailure  = "P2P-PROV-DISC-FAILURE"
+	EventP2PServDiscResp     = "P2P-SERV-DISC-RESP"
+)
+
+// P2P is the Wi-Fi Direct API of a connection.  The connection must be to
+// an interface with P2P support; wpa_supplicant usually runs P2P on a
+// dedicated "p2p-dev-<iface>" control socket.
+type P2P interface {
+	// Find starts searching for peers.  P2P-DEVICE-FOUND events are
+	// reported for each one.
+	Find(P2PFindConfig) error
+
+	// StopFind stops an ongoing Find or Listen.
+	StopFind() error
+
+	// Listen makes the device discoverable for the given duration, or
+	// until StopFind if it is zero.
+	Listen(timeout time.Duration) error
+
+	// Peers returns the device addresses of the peers found so far.
+	Peers() ([]net.HardwareAddr, error)
+
+	// Peer returns information about a peer.
+	Peer(addr net.HardwareAddr) (*P2PPeer, error)
+
+	// Connect starts group owner negotiation with a peer, or joins its
+	// group.  If the config asks for a PIN to be displayed without giving
+	// one, the generated PIN is returned.
+	Connect(addr net.HardwareAddr, config P2PConnectConfig) (string, error)
+
+	// GroupAdd starts an autonomous group with this device as group owner.
+	GroupAdd(P2PGroupConfig) error
+
+	// GroupRemove terminates the group on the given interface, e.g.
+	// "p2p-wlan0-0".
+	GroupRemove(ifname string) error
+
+	// Invite invites a peer to a persistent or running group.
+	Invite(P2PInvitation) error
+
+	// ServDiscReq schedules a service discovery request to a peer, or to
+	// all peers if addr is nil.  query is everything after the address,
+	// e.g. "02000001" (TLVs in hex) or "upnp 10 urn:schemas-upnp-org:device:MediaRenderer:1".
+	// It returns the request ID.
+	ServDiscReq(addr net.HardwareAddr, query string) (string, error)
+}
+
+// P2PFindConfig are the options of P2P.Find.
+type P2PFindConfig struct {
+	// Timeout stops the search after this duration.  Zero searches until
+	// StopFind.
+	Timeout time.Duration
+
+	// Type is "social" to only search the social channels, or
+	// "progressive" to also search the other channels gradually.  Empty
+	// does a full scan first.
+	Type string
+
+	// DevID only reports the peer with this device address.
+	DevID net.HardwareAddr
+}
+
+// P2PWPSMethod is the WPS provisioning method used to connect to a peer.
+type P2PWPSMethod int
+
+const (
+	// P2PPBC uses push button.
+	P2PPBC P2PWPSMethod = iota
+
+	// P2PDisplay displays a PIN which is entered on the peer.
+	P2PDisplay
+
+	// P2PKeypad enters the PIN displayed by the peer.
+	P2PKeypad
+)
+
+// P2PConnectConfig are the options of P2P.Connect.
+type P2PConnectConfig struct {
+	Method P2PWPSMethod
+
+	// PIN is the PIN to use with P2PDisplay or P2PKeypad.  With
+	// P2PDisplay it may be empty, to have wpa_supplicant generate one.
+	PIN string
+
+	// Persistent requests a persistent group.  PersistentID, if set,
+	// reinvokes the persistent group with this network ID.
+	Persistent   bool
+	PersistentID *int
+
+	// Join joins a group the peer already runs instead of negotiating.
+	Join bool
+
+	// Auth only authorizes the peer to connect to us.
+	Auth bool
+
+	// GOIntent is our group owner intent, 0-15.  Nil uses the default
+	// p2p_go_intent.
+	GOIntent *int
+
+	// Freq forces the operating frequency, in MHz.
+	Freq int
+
+	HT40 bool
+	VHT  bool
+
+	// ProvDisc runs provision discovery before negotiating.
+	ProvDisc bool
+}
+
+// P2PGroupConfig are the options of P2P.GroupAdd.
+type P2PGroupConfig struct {
+	// Persistent makes the group persistent.  PersistentID, if set,
+	// restarts the persistent group with this network ID.
+	Persistent   bool
+	PersistentID *int
+
+	// Freq is the operating frequency, in MHz.
+	Freq int
+
+	HT40 bool
+	VHT  bool
+}
+
+// P2PInvitation are the options of P2P.Invite.  Either PersistentID or
+// Group must be set.
+type P2PInvitation struct {
+	Peer net.HardwareAddr
+
+	// PersistentID invites the peer to restart a persistent group.
+	PersistentID *int
+
+	// Group invites the peer to the group running on this interface.
+	Group string
+
+	// GODevAddr is the device address of the group owner, when inviting
+	// to a group we are a client of.
+	GODevAddr net.HardwareAddr
+
+	// Freq is the preferred operating frequency, in MHz.
+	Freq int
+}
+
+// P2PPeer is a peer returned by P2P_PEER.
+type P2PPeer struct {
+	Address           net.HardwareAddr  `json:"address"`
+	DeviceName        string            `json:"device_name"`
+	PrimaryDeviceType string            `json:"pri_dev_type"`
+	Manufacturer      string            `json:"manufacturer"`
+	ModelName         string            `json:"model_name"`
+	ModelNumber       string            `json:"model_number"`
+	SerialNumber      string            `json:"serial_number"`
+	ConfigMethods     int               `json:"config_methods"`
+	DevCapab          int               `json:"dev_capab"`
+	GroupCapab        int               `json:"group_capab"`
+	Level             int               `json:"level"`
+	OperFreq          int               `json:"oper_freq"`
+	Vars              map[string]string `json:"vars"`
+}
+
+// MarshalJSON encodes the peer with its address in the usual
+// colon-separated form, e.g. "02:00:00:00:02:00".
+func (p P2PPeer) MarshalJSON() ([]byte, error) {
+	type peer P2PPeer
+	return json.Marshal(struct {
+		peer
+		Address string `json:"address"`
+	}{peer(p), p.Address.String()})
+}
+
+// UnmarshalJSON decodes the format written by MarshalJSON.
+func (p *P2PPeer) UnmarshalJSON(data []byte) error {
+	type peer P2PPeer
+	v := struct {
+		*peer
+		Address string `json:"address"`
+	}{peer: (*peer)(p)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	addr, err := parseOptionalMAC(v.Address)
+	p.Address = addr
+	return err
+}
+
+// P2PGroup is a group reported by P2P-GROUP-STARTED or P2P-GROUP-REMOVED.
+type P2PGroup struct {
+	// Ifname is the group interface, e.g. "p2p-wlan0-0".
+	Ifname string `json:"ifname"`
+
+	// GO is true if we are the group owner, false if we are a client.
+	GO bool `json:"go"`
+
+	SSID       string           `json:"ssid,omitempty"`
+	Freq       int              `json:"freq,omitempty"`
+	Passphrase string           `json:"passphrase,omitempty"`
+	PSK        string           `json:"psk,omitempty"`
+	GODevAddr  net.HardwareAddr `json:"go_dev_addr,omitempty"`
+	Persistent bool             `json:"persistent,omitempty"`
+}
+
+// MarshalJSON encodes the group with the group owner address in the usual
+// colon-separated form, e.g. "02:00:00:00:02:00".
+func (g P2PGroup) MarshalJSON() ([]byte, error) {
+	type group P2PGroup
+	return json.Marshal(struct {
+		group
+		GODevAddr string `json:"go_dev_addr,omitempty"`
+	}{group(g), g.GODevAddr.String()})
+}
+
+// UnmarshalJSON decodes the format written by MarshalJSON.
+func (g *P2PGroup) UnmarshalJSON(data []byte) error {
+	type group P2PGroup
+	v := struct {
+		*group
+		GODevAddr string `json:"go_dev_addr,omitempty"`
+	}{group: (*group)(g)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	addr, err := parseOptionalMAC(v.GODevAddr)
+	g.GODevAddr = addr
+	return err
+}
+
+// parseOptionalMAC parses a MAC address, or returns nil if s is empty.
+func parseOptionalMAC(s string) (net.HardwareAddr, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return net.ParseMAC(s)
+}
+
+// P2PEvent is a P2P-* event.  Only the fields relevant to the event are
+// set.
+type P2PEvent struct {
+	// Event is one of the EventP2P constants.
+	Event string
+
+	// Peer is the device address of the peer concerned.
+	Peer net.HardwareAddr
+
+	// DeviceName, PrimaryDeviceType and ConfigMethods describe the peer,
+	// for P2P-DEVICE-FOUND.
+	DeviceName        string
+	PrimaryDeviceType string
+	ConfigMethods     int
+
+	// DevPasswdID and GOIntent are the peer's request, for
+	// P2P-GO-NEG-REQUEST.
+	DevPasswdID int
+	GOIntent    int
+
+	// PIN is the PIN to display, for P2P-PROV-DISC-SHOW-PIN.
+	PIN string
+
+	// Group is the group, for P2P-GROUP-STARTED and P2P-GROUP-REMOVED.
+	Group *P2PGroup
+
+	// Reason is why a group was removed, e.g. "REQUESTED".
+	Reason string
+
+	// Status is the failure status, for P2P-GO-NEG-FAILURE,
+	// P2P-PROV-DISC-FAILURE and P2P-INVITATION-RESULT.
+	Status int
+}
+
+// ParseP2PEvent returns the P2PEvent for e, or false if e isn't a P2P
+// event.
+func ParseP2PEvent(e WPAEvent) (*P2PEvent, bool) {
+	if !strings.HasPrefix(e.Event, "P2P-") {
+		return nil, false
+	}
+
+	p := &P2PEvent{Event: e.Event}
+	fields := splitEventFields(e.Line)
+
+	// Most events have the peer address as their first argument, but
+	// the device address is preferred when given.
+	if len(fields) > 1 {
+		p.Peer, _ = net.ParseMAC(fields[1])
+	}
+	if addr, err := net.ParseMAC(e.Arguments["p2p_dev_addr"]); err == nil {
+		p.Peer = addr
+	}
+
+	p.DeviceName = e.Arguments["name"]
+	p.PrimaryDeviceType = e.Arguments["pri_dev_type"]
+	p.ConfigMethods = parseInt(e.Arguments["config_methods"])
+	p.DevPasswdID = parseInt(e.Arguments["dev_passwd_id"])
+	p.GOIntent = parseInt(e.Arguments["go_intent"])
+	p.Status = parseInt(e.Arguments["status"])
+	p.Reason = e.Arguments["reason"]
+
+	switch e.Event {
+	case EventP2PProvDiscShowPin:
+		// P2P-PROV-DISC-SHOW-PIN <addr> <pin> ...
+		if len(fields) > 2 {
+			p.PIN = fields[2]
+		}
+	case EventP2PGroupStarted, EventP2PGroupRemoved:
+		// P2P-GROUP-STARTED <ifname> <GO|client> ssid=... freq=...
+		p.Peer = nil
+		g := &P2PGroup{
+			SSID:       e.Arguments["ssid"],
+			Freq:       parseInt(e.Arguments["freq"]),
+			Passphrase: e.Arguments["passphrase"],
+			PSK:        e.Arguments["psk"],
+			Persistent: strings.Contains(e.Line, "[PERSISTENT]"),
+		}
+		if len(fields) > 2 {
+			g.Ifname = fields[1]
+			g.GO = fields[2] == "GO"
+		}
+		g.GODevAddr, _ = net.ParseMAC(e.Arguments["go_dev_addr"])
+		p.Group = g
+	}
+
+	return p, true
+}
+
+// parseInt parses a decimal or 0x prefixed hex integer, returning zero if it
+// is invalid.
+func parseInt(s string) int {
+	v, _ := strconv.ParseInt(s, 0, 64)
+	return int(v)
+}
+
+// p2p is the implementation of P2P.
+type p2p struct {
+	uc *unixgram
+}
+
+func (uc *unixgram) P2P() P2P {
+	return &p2p{uc: uc}
+}
+
+func (p *p2p) Find(c P2PFindConfig) error {
+	args := []string{"P2P_FIND"}
+	if c.Timeout > 0 {
+		args = append(args, strconv.Itoa(int(c.Timeout/time.Second)))
+	}
+	if c.Type != "" {
+		args = append(args, "type="+c.Type)
+	}
+	if c.DevID != nil {
+		args = append(args, "dev_id="+c.DevID.String())
+	}
+	return p.uc.runCommand(strings.Join(args, " "))
+}
+
+func (p *p2p) StopFind() error {
+	return p.uc.runCommand("P2P_STOP_FIND")
+}
+
+func (p *p2p) Listen(timeout time.Duration) error {
+	if timeout > 0 {
+		return p.uc.runCommand("P2P_LISTEN " + strconv.Itoa(int(timeout/time.Second)))
+	}
+	return p.uc.runCommand("P2P_LISTEN")
+}
+
+func (p *p2p) Peers() ([]net.HardwareAddr, error) {
+	resp, err := p.uc.cmd("P2P_PEERS")
+	if err != nil {
+		return nil, err
+	}
+
+	var peers []net.HardwareAddr
+	for _, ln := range parseLines(resp) {
+		addr, err := net.ParseMAC(ln)
+		if err != nil {
+			return nil, &ParseError{Line: ln, Err: err}
+		}
+		peers = append(peers, addr)
+	}
+	return peers, nil
+}
+
+func (p *p2p) Peer(addr net.HardwareAddr) (*P2PPeer, error) {
+	resp, err := p.uc.cmd("P2P_PEER " + addr.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return parseP2PPeer(bytes.NewReader(resp))
+}
+
+func (p *p2p) Connect(addr net.HardwareAddr, c P2PConnectConfig) (string, error) {
+	args := []string{"P2P_CONNECT", addr.String()}
+	switch c.Method {
+	case P2PPBC:
+		args = append(args, "pbc")
+	case P2PDisplay:
+		if c.PIN == "" {
+			args = append(args, "pin")
+		} else {
+			args = append(args, c.PIN, "display")
+		}
+	case P2PKeypad:
+		args = append(args, c.PIN, "keypad")
+	}
+
+	switch {
+	case c.PersistentID != nil:
+		args = append(args, "persistent="+strconv.Itoa(*c.PersistentID))
+	case c.Persistent:
+		args = append(args, "persistent")
+	}
+	if c.Join {
+		args = append(args, "join")
+	}
+	if c.Auth {
+		args = append(args, "auth")
+	}
+	if c.GOIntent != nil {
+		args = append(args, "go_intent="+strconv.Itoa(*c.GOIntent))
+	}
+	args = appendFreqOptions(args, c.Freq, c.HT40, c.VHT)
+	if c.ProvDisc {
+		args = append(args, "provdisc")
+	}
+
+	resp, err := p.uc.cmd(strings.Join(args, " "))
+	if err != nil {
+		return "", err
+	}
+
+	// The reply is OK, or the generated PIN.
+	reply := strings.TrimSuffix(string(resp), "\n")
+	switch {
+	case reply == "OK":
+		return "", nil
+	case c.Method == P2PDisplay && c.PIN == "" && reply != "" && strings.Trim(reply, "0123456789") == "":
+		return reply, nil
+	}
+	return "", &ParseError{Line: string(resp)}
+}
+
+func (p *p2p) GroupAdd(c P2PGroupConfig) error {
+	args := []string{"P2P_GROUP_ADD"}
+	switch {
+	case c.PersistentID != nil:
+		args = append(args, "persistent="+strconv.Itoa(*c.PersistentID))
+	case c.Persistent:
+		args = append(args, "persistent")
+	}
+	args = appendFreqOptions(args, c.Freq, c.HT40, c.VHT)
+
+	return p.uc.runCommand(strings.Join(args, " "))
+}
+
+func (p *p2p) GroupRemove(ifname string) error {
+	return p.uc.runCommand("P2P_GROUP_REMOVE " + ifname)
+}
+
+func (p *p2p) Invite(inv P2PInvitation) error {
+	args := []string{"P2P_INVITE"}
+	if inv.PersistentID != nil {
+		args = append(args, "persistent="+strconv.Itoa(*inv.PersistentID))
+	} else {
+		args = append(args, "group="+inv.Group)
+	}
+	args = append(args, "peer="+inv.Peer.String())
+	if inv.GODevAddr != nil {
+		args = append(args, "go_dev_addr="+inv.GODevAddr.String())
+	}
+	if inv.Freq > 0 {
+		args = append(args, "freq="+strconv.Itoa(inv.Freq))
+	}
+
+	return p.uc.runCommand(strings.Join(args, " "))
+}
+
+func (p *p2p) ServDiscReq(addr net.HardwareAddr, query string) (string, error) {
+	target := "00:00:00:00:00:00"
+	if addr != nil {
+		target = addr.String()
+	}
+
+	resp, err := p.uc.cmd("P2P_SERV_DISC_REQ " + target + " " + query)
+	if err != nil {
+		return "", err
+	}
+
+	id := strings.TrimSuffix(string(resp), "\n")
+	if id == "" {
+		return "", &ParseError{Line: string(resp)}
+	}
+	return id, nil
+}
+
+// appendFreqOptions adds the operating channel options shared by several
+// P2P commands.
+func appendFreqOptions(args []string, freq int, ht40, vht bool) []string {
+	if freq > 0 {
+		args = append(args, "freq="+strconv.Itoa(freq))
+	}
+	if ht40 {
+		args = append(args, "ht40")
+	}
+	if vht {
+		args = append(args, "vht")
+	}
+	return args
+}
+
+// parseP2PPeer parses the output of P2P_PEER: the device address on the
+// first line, followed by key=value lines.
+func parseP2PPeer(resp *bytes.Reader) (*P2PPeer, error) {
+	s := bufio.NewScanner(resp)
+	if !s.Scan() {
+		return nil, &ParseError{}
+	}
+
+	addr, err := net.ParseMAC(s.Text())
+	if err != nil {
+		return nil, &ParseError{Line: s.Text(), Err: err}
+	}
+
+	peer := &P2PPeer{Address: addr, Vars: make(map[string]string)}
+	for s.Scan() {
+		ln := s.Text()
+		i := strings.IndexByte(ln, '=')
+		if i == -1 {
+			continue
+		}
+		k, v := ln[:i], ln[i+1:]
+		peer.Vars[k] = v
+
+		switch k {
+		case "device_name":
+			peer.DeviceName = v
+		case "pri_dev_type":
+			peer.PrimaryDeviceType = v
+		case "manufacturer":
+			peer.Manufacturer = v
+		case "model_name":
+			peer.ModelName = v
+		case "model_number":
+			peer.ModelNumber = v
+		case "serial_number":
+			peer.SerialNumber = v
+		case "config_methods":
+			peer.ConfigMethods = parseInt(v)
+		case "dev_capab":
+			peer.DevCapab = parseInt(v)
+		case "group_capab":
+			peer.GroupCapab = parseInt(v)
+		case "level":
+			peer.Level = parseInt(v)
+		case "oper_freq":
+			peer.OperFreq = parseInt(v)
+		}
+	}
+
+	return peer, nil
+}
@@ -0,0 +1,152 @@
+package wpasupplicant_test
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-laeo/wpasupplicant"
+)
+
+func TestP2PDiscovery(t *testing.T) {
+	srv, conn := connect(t)
+	srv.Handle("P2P_FIND", func(args string) (string, []string) {
+		return "OK\n", []string{
+			"P2P-DEVICE-FOUND 02:00:00:00:02:00 p2p_dev_addr=02:00:00:00:02:00 pri_dev_type=1-0050F204-1 name='Living Room TV' config_methods=0x188 dev_capab=0x25 group_capab=0x0",
+		}
+	})
+	srv.Handle("P2P_PEERS", func(args string) (string, []string) {
+		return "02:00:00:00:02:00\n", nil
+	})
+	srv.Handle("P2P_PEER", func(args string) (string, []string) {
+		return "02:00:00:00:02:00\npri_dev_type=1-0050F204-1\ndevice_name=Living Room TV\nmanufacturer=ACME\nconfig_methods=0x188\ndev_capab=0x25\ngroup_capab=0x0\nlevel=-42\n", nil
+	})
+
+	events, unsubscribe := conn.Subscribe()
+	defer unsubscribe()
+
+	if err := conn.P2P().Find(wpasupplicant.P2PFindConfig{Type: "social"}); err != nil {
+		t.Fatal(err)
+	}
+	e, ok := wpasupplicant.ParseP2PEvent(waitEvent(t, events, wpasupplicant.EventP2PDeviceFound))
+	if !ok {
+		t.Fatal("not a P2P event")
+	}
+	if e.Peer.String() != "02:00:00:00:02:00" || e.DeviceName != "Living Room TV" || e.ConfigMethods != 0x188 {
+		t.Errorf("got %+v", e)
+	}
+
+	peers, err := conn.P2P().Peers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 1 {
+		t.Fatalf("got peers %v", peers)
+	}
+
+	peer, err := conn.P2P().Peer(peers[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peer.DeviceName != "Living Room TV" || peer.Manufacturer != "ACME" || peer.Level != -42 || peer.DevCapab != 0x25 {
+		t.Errorf("got %+v", peer)
+	}
+
+	cmds := srv.Commands()
+	if cmds[len(cmds)-3] != "P2P_FIND type=social" {
+		t.Errorf("sent %q", cmds[len(cmds)-3])
+	}
+}
+
+func TestP2PConnect(t *testing.T) {
+	srv, conn := connect(t)
+	srv.Handle("P2P_CONNECT", func(args string) (string, []string) {
+		return "12345670\n", []string{
+			"P2P-GROUP-STARTED p2p-wlan0-0 GO ssid=\"DIRECT-ab\" freq=2437 passphrase=\"secret12\" go_dev_addr=02:00:00:00:00:00 [PERSISTENT]",
+		}
+	})
+
+	events, unsubscribe := conn.Subscribe()
+	defer unsubscribe()
+
+	peer, _ := net.ParseMAC("02:00:00:00:02:00")
+	intent := 15
+	pin, err := conn.P2P().Connect(peer, wpasupplicant.P2PConnectConfig{
+		Method:     wpasupplicant.P2PDisplay,
+		Persistent: true,
+		GOIntent:   &intent,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pin != "12345670" {
+		t.Errorf("got PIN %q", pin)
+	}
+	if cmds := srv.Commands(); cmds[len(cmds)-1] != "P2P_CONNECT 02:00:00:00:02:00 pin persistent go_intent=15" {
+		t.Errorf("sent %q", cmds[len(cmds)-1])
+	}
+
+	e, _ := wpasupplicant.ParseP2PEvent(waitEvent(t, events, wpasupplicant.EventP2PGroupStarted))
+	g := e.Group
+	if g == nil || g.Ifname != "p2p-wlan0-0" || !g.GO || g.SSID != "DIRECT-ab" || g.Freq != 2437 || g.Passphrase != "secret12" || !g.Persistent {
+		t.Errorf("got %+v", g)
+	}
+}
+
+func TestP2PJSON(t *testing.T) {
+	addr, _ := net.ParseMAC("02:00:00:00:02:00")
+	peer := wpasupplicant.P2PPeer{
+		Address:    addr,
+		DeviceName: "Living Room TV",
+		Level:      -40,
+		Vars:       map[string]string{"wfd_subelems": "000006"},
+	}
+
+	b, err := json.Marshal(peer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"address":"02:00:00:00:02:00"`) {
+		t.Errorf("address not encoded as a string: %s", b)
+	}
+
+	var decodedPeer wpasupplicant.P2PPeer
+	if err := json.Unmarshal(b, &decodedPeer); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decodedPeer, peer) {
+		t.Errorf("round trip mismatch: got %+v, expect %+v", decodedPeer, peer)
+	}
+
+	group := wpasupplicant.P2PGroup{Ifname: "p2p-wlan0-0", SSID: "DIRECT-ab", Freq: 2437, GODevAddr: addr}
+	b, err = json.Marshal(group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"ifname":"p2p-wlan0-0","go":false,"ssid":"DIRECT-ab","freq":2437,"go_dev_addr":"02:00:00:00:02:00"}`
+	if string(b) != expect {
+		t.Errorf("got %s, expect %s", b, expect)
+	}
+
+	var decodedGroup wpasupplicant.P2PGroup
+	if err := json.Unmarshal(b, &decodedGroup); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decodedGroup, group) {
+		t.Errorf("round trip mismatch: got %+v, expect %+v", decodedGroup, group)
+	}
+
+	// The address is omitted when unknown.
+	b, err = json.Marshal(wpasupplicant.P2PGroup{Ifname: "p2p-wlan0-1", GO: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := `{"ifname":"p2p-wlan0-1","go":true}`; string(b) != expect {
+		t.Errorf("got %s, expect %s", b, expect)
+	}
+	if err := json.Unmarshal([]byte(`{"go_dev_addr":"nonsense"}`), &decodedGroup); err == nil {
+		t.Error("expected an error for an invalid address")
+	}
+}
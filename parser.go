@@ -133,7 +133,7 @@ func parseStatusResults(resp io.Reader) (StatusResult, error) {
 
 // parseScanResults parses the SCAN_RESULTS output from wpa_supplicant.  This
 // is split out from ScanResults() to make testing easier.
-func parseScanResults(resp io.Reader) (res []ScanResult, errs []error) {
+func parseScanResults(resp io.Reader) (res ScanResults, errs []error) {
 	// In an attempt to make our parser more resilient, we start by
 	// parsing the header line and using that to determine the column
 	// order.
@@ -210,8 +210,98 @@ func parseScanResults(resp io.Reader) (res []ScanResult, errs []error) {
 			rssi:      rssi,
 			flags:     flags,
 			ssid:      ssid,
+			security:  parseSecurityFlags(flags),
 		})
 	}
 
 	return
 }
+
+// eventPrefixes are the prefixes of the unsolicited messages which are
+// parsed as events.  Other messages are reported as "MESSAGE".
+var eventPrefixes = []string{"CTRL-", "WPS-", "P2P-", "AP-", "INTERWORKING-", "ANQP-", "RX-ANQP", "RX-HS20-", "HS20-", "RRM-", "WNM-"}
+
+// parseEvent parses an unsolicited message into a WPAEvent.  The event name
+// is the first word of the message, with any "CTRL-EVENT-" prefix removed,
+// and the arguments are the words formatted as key=val.  Quoted values (in
+// single or double quotes) may contain spaces; the quotes are removed.
+// Credential requests are the exception, see EventCtrlReq.
+func parseEvent(data string) WPAEvent {
+	if strings.HasPrefix(data, "CTRL-REQ-") {
+		if e, ok := parseCtrlReq(data); ok {
+			return e
+		}
+	}
+
+	parts := splitEventFields(data)
+	if len(parts) == 0 || !isEvent(parts[0]) {
+		return WPAEvent{
+			Event: "MESSAGE",
+			Line:  data,
+		}
+	}
+
+	e := WPAEvent{
+		Event:     strings.TrimPrefix(parts[0], "CTRL-EVENT-"),
+		Arguments: make(map[string]string),
+		Line:      data,
+	}
+
+	for _, args := range parts[1:] {
+		if i := strings.IndexByte(args, '='); i > 0 {
+			e.Arguments[args[:i]] = unquote(args[i+1:])
+		}
+	}
+
+	return e
+}
+
+// isEvent reports whether name starts with one of the eventPrefixes.
+func isEvent(name string) bool {
+	for _, p := range eventPrefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitEventFields splits an event into space separated fields.  Spaces
+// within single or double quotes don't separate fields.
+func splitEventFields(data string) []string {
+	var fields []string
+	var quote byte
+	start := -1
+	for i := 0; i < len(data); i++ {
+		switch {
+		case quote == 0 && (data[i] == '"' || data[i] == '\''):
+			quote = data[i]
+			if start == -1 {
+				start = i
+			}
+		case quote != 0 && data[i] == quote:
+			quote = 0
+		case data[i] == ' ' && quote == 0:
+			if start != -1 {
+				fields = append(fields, data[start:i])
+				start = -1
+			}
+		default:
+			if start == -1 {
+				start = i
+			}
+		}
+	}
+	if start != -1 {
+		fields = append(fields, data[start:])
+	}
+	return fields
+}
+
+// unquote removes the single or double quotes around a value, if any.
+func unquote(v string) string {
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
@@ -30,7 +30,9 @@ package wpasupplicant
 
 import (
 	"bytes"
+	"encoding/json"
 	"net"
+	"reflect"
 	"testing"
 )
 
@@ -165,3 +167,187 @@ func TestParseStatusResults(t *testing.T) {
 		t.Errorf("Address should be empty. Was %s", res.Address())
 	}
 }
+
+func TestParseSignalPoll(t *testing.T) {
+	testData := "RSSI=-62\n" +
+		"LINKSPEED=866\n" +
+		"NOISE=9999\n" +
+		"FREQUENCY=5180\n" +
+		"WIDTH=80+80 MHz\n" +
+		"CENTER_FRQ1=5210\n" +
+		"CENTER_FRQ2=5530\n" +
+		"AVG_RSSI=-60\n"
+
+	info, err := parseSignalPoll(bytes.NewBufferString(testData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := SignalInfo{RSSI: -62, AvgRSSI: -60, LinkSpeed: 866, Noise: 9999, Frequency: 5180, Width: Width160, CenterFreq1: 5210, CenterFreq2: 5530}
+	if *info != expect {
+		t.Errorf("got %+v, expect %+v", *info, expect)
+	}
+
+	if _, err := parseSignalPoll(bytes.NewBufferString("FAIL\n")); err == nil {
+		t.Error("expected an error for FAIL")
+	}
+}
+
+func TestParseSecurityFlags(t *testing.T) {
+	tests := []struct {
+		flag   string
+		expect Security
+	}{
+		{"WPA2-PSK-CCMP+TKIP", Security{Proto: "WPA2", KeyMgmt: PSK, Ciphers: CCMP | TKIP}},
+		{"WPA2-PSK+SAE-CCMP", Security{Proto: "WPA2", KeyMgmt: PSK | SAE, Ciphers: CCMP}},
+		{"WPA2-EAP-SHA256-CCMP-preauth", Security{Proto: "WPA2", KeyMgmt: IEEE8021X_SHA256, Ciphers: CCMP, Preauth: true}},
+		{"RSN-EAP-SUITE-B-192-GCMP-256", Security{Proto: "RSN", KeyMgmt: IEEE8021X_SUITE_B_192, Ciphers: GCMP_256}},
+		{"WEP", Security{Proto: "WEP"}},
+	}
+
+	for _, test := range tests {
+		got := parseSecurityFlags([]string{"ESS", test.flag})
+		if len(got) != 1 || got[0] != test.expect {
+			t.Errorf("%s: got %+v, expect %+v", test.flag, got, test.expect)
+		}
+	}
+}
+
+func TestScanResultJSON(t *testing.T) {
+	res, errs := parseScanResults(bytes.NewBufferString(parseScanResultTests[0].input))
+	if len(errs) > 0 {
+		t.Fatal("errors parsing scan results")
+	}
+
+	b, err := json.Marshal(res[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `{"bssid":"8a:15:14:8a:46:51","ssid":"WIP-Backoffice","frequency":5560,"rssi":-58,` +
+		`"flags":["WPA-PSK-CCMP+TKIP","WPA2-PSK-CCMP+TKIP","ESS"],` +
+		`"security":[{"proto":"WPA","key_mgmt":["WPA-PSK"],"ciphers":["TKIP","CCMP"]},` +
+		`{"proto":"WPA2","key_mgmt":["WPA-PSK"],"ciphers":["TKIP","CCMP"]}]}`
+	if string(b) != expect {
+		t.Errorf("got %s, expect %s", b, expect)
+	}
+
+	decoded, err := UnmarshalScanResult(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded.BSSID(), res[0].BSSID()) || decoded.SSID() != res[0].SSID() || len(decoded.Security()) != 2 {
+		t.Errorf("round trip mismatch: %+v", decoded)
+	}
+}
+
+func TestStatusResultJSON(t *testing.T) {
+	res, err := parseStatusResults(bytes.NewBufferString("bssid=02:00:00:00:01:00\nfreq=2412\nssid=home-net\n" +
+		"id=0\nmode=station\nkey_mgmt=WPA2-PSK\nwpa_state=COMPLETED\nip_address=192.168.1.20\naddress=02:00:00:00:00:00\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `{"wpa_state":"COMPLETED","key_mgmt":"WPA2-PSK","ip_address":"192.168.1.20","ssid":"home-net",` +
+		`"address":"02:00:00:00:00:00","bssid":"02:00:00:00:01:00","freq":"2412"}`
+	if string(b) != expect {
+		t.Errorf("got %s, expect %s", b, expect)
+	}
+
+	decoded, err := UnmarshalStatusResult(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *decoded.(*statusResult) != *res.(*statusResult) {
+		t.Errorf("round trip mismatch: got %+v, expect %+v", decoded, res)
+	}
+}
+
+func TestConfiguredNetworkJSON(t *testing.T) {
+	res, err := parseListNetworksResult(bytes.NewBufferString("network id / ssid / bssid / flags\n" +
+		"0\thome-net\tany\t[CURRENT]\n1\twork\t02:00:00:00:01:00\t\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 {
+		t.Fatalf("got %d networks, expect 2", len(res))
+	}
+
+	for i, expect := range []string{
+		`{"network_id":"0","ssid":"home-net","bssid":"any","flags":["CURRENT"]}`,
+		`{"network_id":"1","ssid":"work","bssid":"02:00:00:00:01:00","flags":[]}`,
+	} {
+		b, err := json.Marshal(res[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != expect {
+			t.Errorf("got %s, expect %s", b, expect)
+		}
+
+		decoded, err := UnmarshalConfiguredNetwork(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded.NetworkID() != res[i].NetworkID() || decoded.SSID() != res[i].SSID() ||
+			decoded.BSSID() != res[i].BSSID() || len(decoded.Flags()) != len(res[i].Flags()) {
+			t.Errorf("round trip mismatch: got %+v, expect %+v", decoded, res[i])
+		}
+	}
+}
+
+func TestWPAEventJSON(t *testing.T) {
+	e := parseEvent(`CTRL-EVENT-CONNECTED - Connection to 02:00:00:00:01:00 completed [id=0 id_str=]`)
+	e.Interface = "wlan0"
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded WPAEvent
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, e) {
+		t.Errorf("round trip mismatch: got %+v, expect %+v", decoded, e)
+	}
+
+	b, err = json.Marshal(WPAEvent{Event: "SCAN-STARTED", Line: "CTRL-EVENT-SCAN-STARTED "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := `{"event":"SCAN-STARTED","line":"CTRL-EVENT-SCAN-STARTED "}`; string(b) != expect {
+		t.Errorf("got %s, expect %s", b, expect)
+	}
+}
+
+func TestParseEvent(t *testing.T) {
+	e := parseEvent(`WPS-FAIL msg=8 config_error=18 reason=3 ssid="my network" data=a=b`)
+	if e.Event != "WPS-FAIL" {
+		t.Errorf("wrong event %q", e.Event)
+	}
+	for k, v := range map[string]string{"msg": "8", "config_error": "18", "ssid": "my network", "data": "a=b"} {
+		if e.Arguments[k] != v {
+			t.Errorf("argument %s: got %q, expect %q", k, e.Arguments[k], v)
+		}
+	}
+
+	if e := parseEvent("CTRL-EVENT-SCAN-RESULTS "); e.Event != "SCAN-RESULTS" {
+		t.Errorf("wrong event %q", e.Event)
+	}
+	if e := parseEvent("P2P-DEVICE-FOUND 02:00:00:00:02:00 name='Living Room \"TV\"' level=-40"); e.Arguments["name"] != `Living Room "TV"` || e.Arguments["level"] != "-40" {
+		t.Errorf("wrong arguments %q", e.Arguments)
+	}
+	e = parseEvent("CTRL-REQ-PSK_PASSPHRASE-3:PSK or passphrase needed for SSID home-net")
+	if e.Event != "CTRL-REQ" || e.Arguments["field"] != "PSK_PASSPHRASE" || e.Arguments["id"] != "3" || e.Arguments["text"] != "PSK or passphrase needed for SSID home-net" {
+		t.Errorf("wrong credential request %+v", e)
+	}
+	if e := parseEvent("Trying to associate with 02:00:00:00:01:00"); e.Event != "MESSAGE" {
+		t.Errorf("wrong event %q", e.Event)
+	}
+}
@@ -0,0 +1,112 @@
+package wpasupplicant
+
+import (
+	"errors"
+	"strings"
+)
+
+// EventRegdomChange is the name of CTRL-EVENT-REGDOM-CHANGE events, sent
+// when the regulatory domain changes.  See ParseRegdomChangeEvent.
+const EventRegdomChange = "REGDOM-CHANGE"
+
+// WorldRegdom is the country code of the world regulatory domain, used when
+// no country is set.
+const WorldRegdom = "00"
+
+// ErrInvalidCountry is returned by SetCountry for codes which aren't ISO
+// 3166-1 alpha-2 country codes.
+var ErrInvalidCountry = errors.New("invalid ISO 3166-1 alpha-2 country code")
+
+// countryCodes are the officially assigned ISO 3166-1 alpha-2 codes.
+var countryCodes = map[string]bool{}
+
+func init() {
+	for _, code := range strings.Fields(`
+		AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI
+		BJ BL BM BN BO BQ BR BS BT BV BW BY BZ CA CC CD CF CG CH CI CK CL CM CN
+		CO CR CU CV CW CX CY CZ DE DJ DK DM DO DZ EC EE EG EH ER ES ET FI FJ FK
+		FM FO FR GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY HK HM
+		HN HR HT HU ID IE IL IM IN IO IQ IR IS IT JE JM JO JP KE KG KH KI KM KN
+		KP KR KW KY KZ LA LB LC LI LK LR LS LT LU LV LY MA MC MD ME MF MG MH MK
+		ML MM MN MO MP MQ MR MS MT MU MV MW MX MY MZ NA NC NE NF NG NI NL NO NP
+		NR NU NZ OM PA PE PF PG PH PK PL PM PN PR PS PT PW PY QA RE RO RS RU RW
+		SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS ST SV SX SY SZ TC TD TF
+		TG TH TJ TK TL TM TN TO TR TT TV TW TZ UA UG UM US UY UZ VA VC VE VG VI
+		VN VU WF WS YE YT ZA ZM ZW`) {
+		countryCodes[code] = true
+	}
+}
+
+// ValidCountry returns true if code is an ISO 3166-1 alpha-2 country code,
+// in upper case, or WorldRegdom.
+func ValidCountry(code string) bool {
+	return code == WorldRegdom || countryCodes[code]
+}
+
+// RegdomChangeEvent is a CTRL-EVENT-REGDOM-CHANGE event.
+type RegdomChangeEvent struct {
+	// Initiator is what caused the change: "CORE", "USER", "DRIVER",
+	// "COUNTRY_IE" or "BEACON_HINT".
+	Initiator string
+
+	// Type is the kind of domain: "WORLD", "COUNTRY", "INTERSECTION",
+	// "CUSTOM_WORLD" or "UNKNOWN".
+	Type string
+
+	// Alpha2 is the country code, for the COUNTRY type.
+	Alpha2 string
+}
+
+// ParseRegdomChangeEvent returns the RegdomChangeEvent for e, or false if
+// e isn't a CTRL-EVENT-REGDOM-CHANGE event.
+func ParseRegdomChangeEvent(e WPAEvent) (*RegdomChangeEvent, bool) {
+	if e.Event != EventRegdomChange {
+		return nil, false
+	}
+
+	return &RegdomChangeEvent{
+		Initiator: e.Arguments["init"],
+		Type:      e.Arguments["type"],
+		Alpha2:    e.Arguments["alpha2"],
+	}, true
+}
+
+func (uc *unixgram) SetCountry(code string) error {
+	if !ValidCountry(code) {
+		return ErrInvalidCountry
+	}
+	return uc.Settings().Set(SettingCountry, code)
+}
+
+func (uc *unixgram) Country() (string, error) {
+	return uc.Settings().Get(SettingCountry)
+}
+
+func (uc *unixgram) ChannelAllowed(freq int) (SupportedChannel, bool, error) {
+	channels, err := uc.supportedChannels()
+	if err != nil {
+		return SupportedChannel{}, false, err
+	}
+
+	ch, allowed := (&Capabilities{Channels: channels}).ChannelAllowed(freq)
+	return ch, allowed, nil
+}
+
+// supportedChannels queries the channels enabled under the current
+// regulatory domain, with GET_CAPABILITY freq or, on versions without it,
+// GET_CAPABILITY channels.
+func (uc *unixgram) supportedChannels() ([]SupportedChannel, error) {
+	resp, err := uc.cmd("GET_CAPABILITY freq")
+	if err == nil {
+		return parseCapabilityFreq(strings.TrimSuffix(string(resp), "\n")), nil
+	}
+	if !errors.Is(err, ErrFail) && !errors.Is(err, ErrUnknownCommand) {
+		return nil, err
+	}
+
+	resp, err = uc.cmd("GET_CAPABILITY channels")
+	if err != nil {
+		return nil, err
+	}
+	return parseCapabilityChannels(strings.TrimSuffix(string(resp), "\n")), nil
+}
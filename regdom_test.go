@@ -0,0 +1,69 @@
+package wpasupplicant_test
+
+import (
+	"testing"
+
+	"github.com/go-laeo/wpasupplicant"
+)
+
+func TestSetCountry(t *testing.T) {
+	_, conn := connect(t)
+
+	events, unsubscribe := conn.Subscribe()
+	defer unsubscribe()
+
+	for _, code := range []string{"us", "USA", "XX", "UK"} {
+		if err := conn.SetCountry(code); err != wpasupplicant.ErrInvalidCountry {
+			t.Errorf("%s: got %v", code, err)
+		}
+	}
+
+	if err := conn.SetCountry("DE"); err != nil {
+		t.Fatal(err)
+	}
+	e, ok := wpasupplicant.ParseRegdomChangeEvent(waitEvent(t, events, wpasupplicant.EventRegdomChange))
+	if !ok || e.Initiator != "USER" || e.Type != "COUNTRY" || e.Alpha2 != "DE" {
+		t.Errorf("got %+v", e)
+	}
+	if country, err := conn.Country(); err != nil || country != "DE" {
+		t.Errorf("got %q, %v", country, err)
+	}
+}
+
+func TestChannelAllowed(t *testing.T) {
+	srv, conn := connect(t)
+
+	tests := []struct {
+		freq    int
+		band    wpasupplicant.Band
+		channel int
+		allowed bool
+		dfs     bool
+	}{
+		{2437, wpasupplicant.Band2GHz, 6, true, false},
+		{2472, wpasupplicant.Band2GHz, 13, false, false},
+		{5180, wpasupplicant.Band5GHz, 36, true, false},
+		{5260, wpasupplicant.Band5GHz, 52, true, true},
+		{5745, wpasupplicant.Band5GHz, 149, false, false},
+		{2400, wpasupplicant.BandUnknown, 0, false, false},
+	}
+
+	for _, test := range tests {
+		ch, allowed, err := conn.ChannelAllowed(test.freq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ch.Band != test.band || ch.Number != test.channel || allowed != test.allowed || ch.DFS != test.dfs {
+			t.Errorf("%d MHz: got %+v allowed %t", test.freq, ch, allowed)
+		}
+	}
+
+	// Older versions only list the enabled channel numbers.
+	srv.SetCapability("freq", "")
+	if ch, allowed, err := conn.ChannelAllowed(5260); err != nil || !allowed || ch.Number != 52 {
+		t.Errorf("got %+v allowed %t, %v", ch, allowed, err)
+	}
+	if _, allowed, err := conn.ChannelAllowed(2472); err != nil || allowed {
+		t.Errorf("got allowed %t, %v", allowed, err)
+	}
+}
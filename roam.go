@@ -0,0 +1,341 @@
+package wpasupplicant
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventConnected is the name of CTRL-EVENT-CONNECTED events, sent when a
+// connection, or a roam, completes.
+const EventConnected = "CONNECTED"
+
+// SignalInfo is the reply to SIGNAL_POLL: the state of the current link.
+type SignalInfo struct {
+	// RSSI is the signal level, in dBm, and AvgRSSI its average.
+	RSSI    int
+	AvgRSSI int
+
+	// LinkSpeed is the transmit rate, in Mbps.
+	LinkSpeed int
+
+	// Noise is the noise level in dBm, or 9999 if the driver doesn't
+	// report it.
+	Noise int
+
+	// Frequency is the frequency of the primary channel, in MHz.
+	Frequency int
+
+	// Width is the channel width, and CenterFreq1 and CenterFreq2 the
+	// center frequencies of its segments.
+	Width       ChannelWidth
+	CenterFreq1 int
+	CenterFreq2 int
+}
+
+func (uc *unixgram) Roam(bssid net.HardwareAddr) error {
+	return uc.runCommand("ROAM " + bssid.String())
+}
+
+func (uc *unixgram) SignalPoll() (*SignalInfo, error) {
+	resp, err := uc.cmd("SIGNAL_POLL")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSignalPoll(strings.NewReader(string(resp)))
+}
+
+// parseSignalPoll parses the key=value output of SIGNAL_POLL.
+func parseSignalPoll(resp io.Reader) (*SignalInfo, error) {
+	info := &SignalInfo{}
+	found := false
+
+	s := bufio.NewScanner(resp)
+	for s.Scan() {
+		ln := s.Text()
+		i := strings.IndexByte(ln, '=')
+		if i < 0 {
+			return nil, &ParseError{Line: ln}
+		}
+		key, v := ln[:i], ln[i+1:]
+		found = true
+
+		var err error
+		switch key {
+		case "RSSI":
+			info.RSSI, err = strconv.Atoi(v)
+		case "AVG_RSSI":
+			info.AvgRSSI, err = strconv.Atoi(v)
+		case "LINKSPEED":
+			info.LinkSpeed, err = strconv.Atoi(v)
+		case "NOISE":
+			info.Noise, err = strconv.Atoi(v)
+		case "FREQUENCY":
+			info.Frequency, err = strconv.Atoi(v)
+		case "CENTER_FRQ1":
+			info.CenterFreq1, err = strconv.Atoi(v)
+		case "CENTER_FRQ2":
+			info.CenterFreq2, err = strconv.Atoi(v)
+		case "WIDTH":
+			// e.g. "20 MHz (no HT)", "80 MHz" or "80+80 MHz".
+			if strings.HasPrefix(v, "80+80") {
+				info.Width = Width160
+			} else if f := strings.Fields(v); len(f) > 0 {
+				var w int
+				w, err = strconv.Atoi(f[0])
+				info.Width = ChannelWidth(w)
+			}
+		}
+		if err != nil {
+			return nil, &ParseError{Line: ln, Err: err}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, &ParseError{}
+	}
+	return info, nil
+}
+
+// roamTimeout is how long a Roamer waits for a roam to complete.
+const roamTimeout = 10 * time.Second
+
+// RoamConfig configures a Roamer.  Zero fields take the default values.
+type RoamConfig struct {
+	// Threshold is the signal level, in dBm, below which candidates are
+	// looked for.  The default is -70.
+	Threshold int
+
+	// Hysteresis is how many dB stronger than the current BSS a
+	// candidate must be.  The default is 8.
+	Hysteresis int
+
+	// PollInterval is how often the signal is polled.  The default is 2
+	// seconds.
+	PollInterval time.Duration
+
+	// Cooldown is how long a BSS isn't roamed to after roaming away from
+	// it, or after failing to roam to it.  The default is 1 minute.
+	Cooldown time.Duration
+}
+
+// Roam is a roam made by a Roamer.
+type Roam struct {
+	From, To net.HardwareAddr
+
+	// FromRSSI is the signal level of From before roaming, and ToRSSI the
+	// signal level of To in the scan results.
+	FromRSSI int
+	ToRSSI   int
+
+	// Start is when the ROAM command was sent, and Duration how long it
+	// took for CTRL-EVENT-CONNECTED to arrive.
+	Start    time.Time
+	Duration time.Duration
+
+	// Err is set if the roam failed.
+	Err error
+}
+
+// RoamStats are the statistics of a Roamer.
+type RoamStats struct {
+	// Attempts counts the roams tried, and Failures the ones which
+	// failed.
+	Attempts int
+	Failures int
+
+	// Total and Max are the total and longest durations of the
+	// successful roams.
+	Total time.Duration
+	Max   time.Duration
+
+	// Last is the last roam, or nil.
+	Last *Roam
+}
+
+// Average returns the average duration of the successful roams.
+func (s RoamStats) Average() time.Duration {
+	if n := s.Attempts - s.Failures; n > 0 {
+		return s.Total / time.Duration(n)
+	}
+	return 0
+}
+
+// Roamer roams between the BSSs of the current network from the client
+// side: when the signal of the current BSS is weak, it scans and roams to
+// the strongest BSS of the same SSID if it is stronger by the hysteresis.
+// It complements wpa_supplicant's own roaming, which tends to stick to
+// access points for too long.
+type Roamer struct {
+	conn   Conn
+	config RoamConfig
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	lock     sync.Mutex
+	cooldown map[string]time.Time
+	stats    RoamStats
+}
+
+// NewRoamer starts roaming with conn, until ctx is done or Close is called.
+func NewRoamer(ctx context.Context, conn Conn, config RoamConfig) *Roamer {
+	if config.Threshold == 0 {
+		config.Threshold = -70
+	}
+	if config.Hysteresis == 0 {
+		config.Hysteresis = 8
+	}
+	if config.PollInterval == 0 {
+		config.PollInterval = 2 * time.Second
+	}
+	if config.Cooldown == 0 {
+		config.Cooldown = time.Minute
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	r := &Roamer{
+		conn:     conn,
+		config:   config,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		cooldown: make(map[string]time.Time),
+	}
+
+	go r.run(ctx)
+
+	return r
+}
+
+// Close stops roaming.
+func (r *Roamer) Close() error {
+	r.cancel()
+	<-r.done
+	return nil
+}
+
+// Stats returns the roaming statistics.
+func (r *Roamer) Stats() RoamStats {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.stats
+}
+
+// run polls the signal until ctx is done.
+func (r *Roamer) run(ctx context.Context) {
+	defer close(r.done)
+
+	events, unsubscribe := r.conn.Subscribe()
+	defer unsubscribe()
+
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		r.check(ctx, events)
+	}
+}
+
+// check roams if the signal is weak and there is a better candidate.
+func (r *Roamer) check(ctx context.Context, events <-chan WPAEvent) {
+	signal, err := r.conn.SignalPoll()
+	if err != nil || signal.RSSI >= r.config.Threshold {
+		return
+	}
+
+	status, err := r.conn.Status()
+	if err != nil || status.WPAState() != "COMPLETED" {
+		return
+	}
+	current, err := net.ParseMAC(status.BSSID())
+	if err != nil {
+		return
+	}
+
+	results, err := scanWait(ctx, r.conn, events)
+	if err != nil {
+		return
+	}
+
+	if to := r.candidate(results, status.SSID(), current, signal.RSSI); to != nil {
+		r.roam(ctx, events, Roam{From: current, To: to.BSSID(), FromRSSI: signal.RSSI, ToRSSI: to.RSSI()})
+	}
+}
+
+// candidate returns the strongest BSS to roam to, or nil.
+func (r *Roamer) candidate(results ScanResults, ssid string, current net.HardwareAddr, rssi int) ScanResult {
+	now := time.Now()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var best ScanResult
+	for _, res := range results {
+		switch {
+		case res.SSID() != ssid || res.BSSID().String() == current.String():
+		case res.RSSI() < rssi+r.config.Hysteresis:
+		case now.Before(r.cooldown[res.BSSID().String()]):
+		case best == nil || res.RSSI() > best.RSSI():
+			best = res
+		}
+	}
+	return best
+}
+
+// roam roams and waits for the connection to complete.
+func (r *Roamer) roam(ctx context.Context, events <-chan WPAEvent, roam Roam) {
+	roam.Start = time.Now()
+	roam.Err = r.conn.Roam(roam.To)
+	if roam.Err == nil {
+		ctx, cancel := context.WithTimeout(ctx, roamTimeout)
+		roam.Err = await(ctx, events, func(e WPAEvent) (bool, error) {
+			// CTRL-EVENT-CONNECTED - Connection to <bssid> completed ...
+			if f := splitEventFields(e.Line); e.Event == EventConnected && len(f) > 4 {
+				if f[4] != roam.To.String() {
+					return true, errors.New("connected to " + f[4])
+				}
+				return true, nil
+			}
+			return false, nil
+		})
+		cancel()
+		roam.Duration = time.Since(roam.Start)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	// Don't roam straight back, nor try a failing BSS again right away.
+	r.cooldown[roam.From.String()] = time.Now().Add(r.config.Cooldown)
+	r.stats.Attempts++
+	if roam.Err != nil {
+		r.cooldown[roam.To.String()] = time.Now().Add(r.config.Cooldown)
+		r.stats.Failures++
+	} else {
+		r.stats.Total += roam.Duration
+		if roam.Duration > r.stats.Max {
+			r.stats.Max = roam.Duration
+		}
+	}
+	r.stats.Last = &roam
+
+	for key, until := range r.cooldown {
+		if time.Now().After(until) {
+			delete(r.cooldown, key)
+		}
+	}
+}
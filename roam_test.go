@@ -0,0 +1,67 @@
+package wpasupplicant_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-laeo/wpasupplicant"
+	"github.com/go-laeo/wpasupplicant/wpatest"
+)
+
+func TestRoamer(t *testing.T) {
+	srv, conn := connect(t)
+	near := wpatest.BSS{BSSID: "02:00:00:00:01:00", SSID: "warehouse", Frequency: 5180, Signal: -45, Flags: "[WPA2-PSK-CCMP][ESS]"}
+	far := wpatest.BSS{BSSID: "02:00:00:00:02:00", SSID: "warehouse", Frequency: 5500, Signal: -85, Flags: "[WPA2-PSK-CCMP][ESS]"}
+	srv.SetBSSs(near, far)
+
+	id, err := conn.AddNetwork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.SetNetwork(id, "ssid", "warehouse"); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.SelectNetwork(id); err != nil {
+		t.Fatal(err)
+	}
+
+	roamer := wpasupplicant.NewRoamer(context.Background(), conn, wpasupplicant.RoamConfig{PollInterval: 10 * time.Millisecond})
+	defer roamer.Close()
+
+	// The client walks away from the access point it is connected to.
+	near.Signal, far.Signal = -78, -50
+	srv.SetBSSs(near, far)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for roamer.Stats().Attempts == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	stats := roamer.Stats()
+	if stats.Attempts != 1 || stats.Failures != 0 || stats.Last == nil {
+		t.Fatalf("got %+v", stats)
+	}
+	if last := stats.Last; last.From.String() != near.BSSID || last.To.String() != far.BSSID || last.FromRSSI != -78 || last.ToRSSI != -50 || last.Duration <= 0 {
+		t.Errorf("got %+v", last)
+	}
+	if stats.Average() != stats.Total || stats.Max != stats.Total {
+		t.Errorf("got %+v", stats)
+	}
+
+	signal, err := conn.SignalPoll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signal.RSSI != -50 || signal.Frequency != 5500 {
+		t.Errorf("got %+v", signal)
+	}
+
+	// The previous access point is now cooling down.
+	near.Signal, far.Signal = -40, -75
+	srv.SetBSSs(near, far)
+	time.Sleep(100 * time.Millisecond)
+	if stats := roamer.Stats(); stats.Attempts != 1 {
+		t.Errorf("roamed back: %+v", stats.Last)
+	}
+}
@@ -0,0 +1,232 @@
+package wpasupplicant
+
+import (
+	"encoding/hex"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Radio resource management (802.11k) and BSS transition management
+// (802.11v) event names.
+const (
+	EventNeighborReport       = "RRM-NEIGHBOR-REP-RECEIVED"
+	EventNeighborReportFailed = "RRM-NEIGHBOR-REP-REQUEST-FAILED"
+	EventWNMBSSTMResp         = "WNM-BSS-TM-RESP"
+
+	// BSS-TM events are reported as CTRL-EVENT-BSS-TM-<kind>, e.g.
+	// "BSS-TM-REQ".  See ParseBSSTMEvent.
+	eventBSSTMPrefix = "BSS-TM-"
+)
+
+// BSS transition management query reasons, for WNMBSSQuery.
+const (
+	BTMReasonUnspecified      = 0
+	BTMReasonFrameLoss        = 1
+	BTMReasonDelay            = 2
+	BTMReasonQoSCapacity      = 3
+	BTMReasonFirstAssociation = 4
+	BTMReasonLoadBalancing    = 5
+	BTMReasonBetterAP         = 6
+	BTMReasonLowRSSI          = 16
+)
+
+// NeighborReportConfig are the options of a neighbor report request.
+type NeighborReportConfig struct {
+	// SSID requests the neighbors of another SSID than the current one.
+	SSID string
+
+	// LCI and Civic request the location of the neighbors.
+	LCI   bool
+	Civic bool
+}
+
+// Neighbor is an access point reported by the current one, in a neighbor
+// report or as a transition candidate.
+type Neighbor struct {
+	BSSID net.HardwareAddr
+
+	// Info is the BSSID Information field (reachability, security,
+	// capabilities, ...).
+	Info uint32
+
+	// OpClass and ChannelNumber are the operating class and channel.
+	OpClass       int
+	ChannelNumber int
+
+	PHYType int
+
+	// LCI and Civic are the raw location elements, if requested.
+	LCI   []byte
+	Civic []byte
+}
+
+// Channel returns the channel of the neighbor, or false if its operating
+// class is unknown.
+func (n *Neighbor) Channel() (Channel, bool) {
+	ch, err := NewChannel(opClassBand(n.OpClass), n.ChannelNumber)
+	return ch, err == nil
+}
+
+// opClassBand returns the band of a global operating class.
+func opClassBand(opClass int) Band {
+	switch {
+	case opClass >= 81 && opClass <= 84:
+		return Band2GHz
+	case opClass >= 115 && opClass <= 130:
+		return Band5GHz
+	case opClass >= 131 && opClass <= 137:
+		return Band6GHz
+	case opClass >= 180 && opClass <= 185:
+		return Band60GHz
+	}
+	return BandUnknown
+}
+
+// ParseNeighborReport returns the neighbor of an RRM-NEIGHBOR-REP-RECEIVED
+// event, or false if e isn't one.  Each neighbor is reported in its own
+// event.
+func ParseNeighborReport(e WPAEvent) (*Neighbor, bool) {
+	if e.Event != EventNeighborReport {
+		return nil, false
+	}
+
+	// RRM-NEIGHBOR-REP-RECEIVED bssid=<bssid> info=0x1f3 op_class=115
+	// chan=36 phy_type=9 [lci=<hex>] [civic=<hex>]
+	n := &Neighbor{}
+	n.BSSID, _ = net.ParseMAC(e.Arguments["bssid"])
+	n.Info = uint32(parseInt(e.Arguments["info"]))
+	n.OpClass, _ = strconv.Atoi(e.Arguments["op_class"])
+	n.ChannelNumber, _ = strconv.Atoi(e.Arguments["chan"])
+	n.PHYType, _ = strconv.Atoi(e.Arguments["phy_type"])
+	n.LCI, _ = hex.DecodeString(e.Arguments["lci"])
+	n.Civic, _ = hex.DecodeString(e.Arguments["civic"])
+	return n, true
+}
+
+// BSSTMEvent is a BSS transition management event: WNM-BSS-TM-RESP or a
+// CTRL-EVENT-BSS-TM-* event.  Only the fields relevant to the event are
+// set.
+type BSSTMEvent struct {
+	// Event is EventWNMBSSTMResp or the name of the BSS-TM event, e.g.
+	// "BSS-TM-REQ".
+	Event string
+
+	// BSSID is the access point concerned.
+	BSSID net.HardwareAddr
+
+	DialogToken int
+
+	// ReqMode, DisassocTimer and ValidityInterval are the fields of a
+	// transition request.
+	ReqMode          int
+	DisassocTimer    int
+	ValidityInterval int
+
+	// StatusCode, BSSTerminationDelay and TargetBSSID are the fields of a
+	// transition response.  StatusCode 0 means the transition is
+	// accepted.
+	StatusCode          int
+	BSSTerminationDelay int
+	TargetBSSID         net.HardwareAddr
+
+	// Candidates are the transition candidates, from the
+	// neighbor=<bssid>,<info>,<op class>,<channel>,<phy type> fields, in
+	// order of preference.
+	Candidates []Neighbor
+}
+
+// ParseBSSTMEvent returns the BSSTMEvent for e, or false if e isn't a BSS
+// transition management event.
+func ParseBSSTMEvent(e WPAEvent) (*BSSTMEvent, bool) {
+	if e.Event != EventWNMBSSTMResp && !strings.HasPrefix(e.Event, eventBSSTMPrefix) {
+		return nil, false
+	}
+
+	ev := &BSSTMEvent{Event: e.Event}
+	fields := splitEventFields(e.Line)
+
+	// The BSSID is either the first field or the addr argument.
+	if len(fields) > 1 {
+		ev.BSSID, _ = net.ParseMAC(fields[1])
+	}
+	if ev.BSSID == nil {
+		ev.BSSID, _ = net.ParseMAC(e.Arguments["addr"])
+	}
+
+	ev.DialogToken = parseInt(e.Arguments["dialog_token"])
+	ev.ReqMode = parseInt(e.Arguments["req_mode"])
+	ev.DisassocTimer = parseInt(e.Arguments["disassoc_timer"])
+	ev.ValidityInterval = parseInt(e.Arguments["validity_interval"])
+	ev.StatusCode = parseInt(e.Arguments["status_code"])
+	ev.BSSTerminationDelay = parseInt(e.Arguments["bss_termination_delay"])
+	ev.TargetBSSID, _ = net.ParseMAC(e.Arguments["target_bssid"])
+
+	for _, f := range fields {
+		if strings.HasPrefix(f, "neighbor=") {
+			if n, ok := parseNeighbor(strings.TrimPrefix(f, "neighbor=")); ok {
+				ev.Candidates = append(ev.Candidates, n)
+			}
+		}
+	}
+
+	return ev, true
+}
+
+// parseNeighbor parses <bssid>,<info>,<op class>,<channel>,<phy type>.
+func parseNeighbor(s string) (Neighbor, bool) {
+	f := strings.Split(s, ",")
+	if len(f) < 5 {
+		return Neighbor{}, false
+	}
+
+	var n Neighbor
+	var err error
+	if n.BSSID, err = net.ParseMAC(f[0]); err != nil {
+		return Neighbor{}, false
+	}
+	n.Info = uint32(parseInt(f[1]))
+	n.OpClass, _ = strconv.Atoi(f[2])
+	n.ChannelNumber, _ = strconv.Atoi(f[3])
+	n.PHYType, _ = strconv.Atoi(f[4])
+	return n, true
+}
+
+func (uc *unixgram) NeighborReportRequest(config NeighborReportConfig) error {
+	cmd := "NEIGHBOR_REP_REQUEST"
+	if config.SSID != "" {
+		// The SSID is sent hex encoded, which needs no quoting.
+		cmd += " ssid=" + hex.EncodeToString([]byte(config.SSID))
+	}
+	if config.LCI {
+		cmd += " lci"
+	}
+	if config.Civic {
+		cmd += " civic"
+	}
+	return uc.runCommand(cmd)
+}
+
+func (uc *unixgram) WNMBSSQuery(reason int, listCandidates bool) error {
+	cmd := "WNM_BSS_QUERY " + strconv.Itoa(reason)
+	if listCandidates {
+		cmd += " list"
+	}
+	return uc.runCommand(cmd)
+}
+
+func (uc *unixgram) WNMSleep(enter bool, interval int) error {
+	if !enter {
+		return uc.runCommand("WNM_SLEEP exit")
+	}
+
+	cmd := "WNM_SLEEP enter"
+	if interval > 0 {
+		cmd += " interval=" + strconv.Itoa(interval)
+	}
+	return uc.runCommand(cmd)
+}
+
+func (uc *unixgram) FTDS(bssid net.HardwareAddr) error {
+	return uc.runCommand("FT_DS " + bssid.String())
+}
@@ -0,0 +1,95 @@
+package wpasupplicant_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/go-laeo/wpasupplicant"
+	"github.com/go-laeo/wpasupplicant/wpatest"
+)
+
+func TestNeighborReport(t *testing.T) {
+	srv, conn := connect(t)
+	srv.SetBSSs(
+		wpatest.BSS{BSSID: "02:00:00:00:01:00", SSID: "office", Frequency: 5180, Signal: -50, Flags: "[WPA2-PSK-CCMP][ESS]"},
+		wpatest.BSS{BSSID: "02:00:00:00:02:00", SSID: "office", Frequency: 6135, Signal: -60, Flags: "[WPA2-PSK-CCMP][ESS]"},
+	)
+
+	id, err := conn.AddNetwork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.SetNetwork(id, "ssid", "office"); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.SelectNetwork(id); err != nil {
+		t.Fatal(err)
+	}
+
+	events, unsubscribe := conn.Subscribe()
+	defer unsubscribe()
+
+	if err := conn.NeighborReportRequest(wpasupplicant.NeighborReportConfig{SSID: "office", LCI: true}); err != nil {
+		t.Fatal(err)
+	}
+	if cmds := srv.Commands(); cmds[len(cmds)-1] != "NEIGHBOR_REP_REQUEST ssid=6f6666696365 lci" {
+		t.Errorf("sent %q", cmds[len(cmds)-1])
+	}
+	n, ok := wpasupplicant.ParseNeighborReport(waitEvent(t, events, wpasupplicant.EventNeighborReport))
+	if !ok || n.BSSID.String() != "02:00:00:00:02:00" || n.Info != 0x8f || n.OpClass != 131 || n.PHYType != 9 {
+		t.Fatalf("got %+v", n)
+	}
+	if ch, ok := n.Channel(); !ok || ch.Band != wpasupplicant.Band6GHz || ch.Number != 37 || !ch.PSC() {
+		t.Errorf("got %v, %v", ch, ok)
+	}
+
+	if err := conn.FTDS(n.BSSID); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, events, wpasupplicant.EventConnected)
+	if status, err := conn.Status(); err != nil || status.BSSID() != "02:00:00:00:02:00" {
+		t.Errorf("got %v, %v", status, err)
+	}
+}
+
+func TestBSSTransition(t *testing.T) {
+	srv, conn := connect(t)
+	srv.Handle("WNM_BSS_QUERY", func(args string) (string, []string) {
+		return "OK\n", []string{
+			"CTRL-EVENT-BSS-TM-REQ 02:00:00:00:01:00 dialog_token=3 req_mode=0x05 disassoc_timer=0 validity_interval=200 " +
+				"neighbor=02:00:00:00:02:00,0x8f,115,40,9 neighbor=02:00:00:00:03:00,0x8f,81,6,7",
+		}
+	})
+
+	events, unsubscribe := conn.Subscribe()
+	defer unsubscribe()
+
+	if err := conn.WNMBSSQuery(wpasupplicant.BTMReasonLowRSSI, true); err != nil {
+		t.Fatal(err)
+	}
+	if cmds := srv.Commands(); cmds[len(cmds)-1] != "WNM_BSS_QUERY 16 list" {
+		t.Errorf("sent %q", cmds[len(cmds)-1])
+	}
+
+	tm, ok := wpasupplicant.ParseBSSTMEvent(waitEvent(t, events, "BSS-TM-REQ"))
+	if !ok || tm.BSSID.String() != "02:00:00:00:01:00" || tm.DialogToken != 3 || tm.ReqMode != 5 || tm.ValidityInterval != 200 {
+		t.Fatalf("got %+v", tm)
+	}
+	if len(tm.Candidates) != 2 || tm.Candidates[0].BSSID.String() != "02:00:00:00:02:00" || tm.Candidates[1].ChannelNumber != 6 {
+		t.Fatalf("got %+v", tm.Candidates)
+	}
+	if ch, ok := tm.Candidates[1].Channel(); !ok || ch.Freq != 2437 {
+		t.Errorf("got %v, %v", ch, ok)
+	}
+
+	srv.Emit("WNM-BSS-TM-RESP 02:00:00:00:01:00 dialog_token=3 status_code=0 bss_termination_delay=0 target_bssid=02:00:00:00:02:00")
+	tm, ok = wpasupplicant.ParseBSSTMEvent(waitEvent(t, events, wpasupplicant.EventWNMBSSTMResp))
+	target, _ := net.ParseMAC("02:00:00:00:02:00")
+	if !ok || tm.StatusCode != 0 || tm.TargetBSSID.String() != target.String() {
+		t.Errorf("got %+v", tm)
+	}
+
+	if _, ok := wpasupplicant.ParseBSSTMEvent(wpasupplicant.WPAEvent{Event: "SCAN-RESULTS"}); ok {
+		t.Error("parsed SCAN-RESULTS as a BSS-TM event")
+	}
+}
@@ -0,0 +1,298 @@
+package wpasupplicant
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Scan event names.
+const (
+	EventScanStarted = "SCAN-STARTED"
+	EventScanResults = "SCAN-RESULTS"
+	EventBSSAdded    = "BSS-ADDED"
+	EventBSSRemoved  = "BSS-REMOVED"
+)
+
+// CachedBSS is an entry of a ScanCache.
+type CachedBSS struct {
+	ScanResult ScanResult
+
+	// FirstSeen is when the BSS was added to the cache, and LastSeen
+	// when it was last reported by wpa_supplicant.
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// ScanChangeKind is the kind of a ScanChange.
+type ScanChangeKind int
+
+const (
+	BSSAdded ScanChangeKind = iota
+	BSSRemoved
+	BSSSignalChanged
+)
+
+func (k ScanChangeKind) String() string {
+	switch k {
+	case BSSAdded:
+		return "added"
+	case BSSRemoved:
+		return "removed"
+	case BSSSignalChanged:
+		return "signal changed"
+	}
+	return "unknown"
+}
+
+// ScanChange is a change of a ScanCache, reported by Watch.
+type ScanChange struct {
+	Kind ScanChangeKind
+
+	// BSS is the entry concerned, as it was before removal for
+	// BSSRemoved.
+	BSS CachedBSS
+
+	// PreviousRSSI is the signal level before a BSSSignalChanged.
+	PreviousRSSI int
+}
+
+// ScanCache keeps a table of the BSSs seen by wpa_supplicant, updated from
+// the SCAN-RESULTS, BSS-ADDED and BSS-REMOVED events, so that scan results
+// don't need to be fetched again and again.  Additions are fetched once per
+// scan rather than for each BSS.
+type ScanCache struct {
+	conn        Conn
+	unsubscribe func()
+	done        chan struct{}
+
+	lock    sync.Mutex
+	entries map[string]*CachedBSS
+
+	watchLock sync.Mutex
+	watchers  map[chan ScanChange]struct{}
+}
+
+// NewScanCache starts caching the scan results of conn.  The cache is
+// filled with the current results.  Close stops it.
+func NewScanCache(conn Conn) (*ScanCache, error) {
+	c := &ScanCache{
+		conn:     conn,
+		done:     make(chan struct{}),
+		entries:  make(map[string]*CachedBSS),
+		watchers: make(map[chan ScanChange]struct{}),
+	}
+
+	// Subscribe before loading, so that no change is missed.
+	events, unsubscribe := conn.Subscribe()
+	c.unsubscribe = unsubscribe
+	if errs := c.refresh(); len(errs) > 0 {
+		unsubscribe()
+		return nil, errs[0]
+	}
+
+	go c.run(events)
+
+	return c, nil
+}
+
+// Close stops updating the cache and closes the Watch channels.
+func (c *ScanCache) Close() error {
+	c.unsubscribe()
+	<-c.done
+
+	c.watchLock.Lock()
+	defer c.watchLock.Unlock()
+	for w := range c.watchers {
+		delete(c.watchers, w)
+		close(w)
+	}
+	return nil
+}
+
+// scanCacheSettle is how long the cache waits for SCAN-RESULTS after
+// BSS-ADDED before refreshing anyway, e.g. because the BSS was added
+// outside of a scan or SCAN-RESULTS was dropped.
+const scanCacheSettle = 100 * time.Millisecond
+
+// run applies events until the subscription is closed.  A scan adds BSSs
+// in bursts which may exceed the subscription queue, so BSS-ADDED only
+// marks the cache as outdated, and the cache is refreshed once with
+// SCAN-RESULTS.
+func (c *ScanCache) run(events <-chan WPAEvent) {
+	defer close(c.done)
+
+	// settle is set while BSSs were added since the last refresh.
+	var settle <-chan time.Time
+
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+
+			switch e.Event {
+			case EventScanResults:
+				c.refresh()
+				settle = nil
+			case EventBSSAdded:
+				if settle == nil {
+					settle = time.After(scanCacheSettle)
+				}
+			case EventBSSRemoved:
+				// BSS-REMOVED <id> <bssid>
+				if f := splitEventFields(e.Line); len(f) > 2 {
+					if bssid, err := net.ParseMAC(f[2]); err == nil {
+						c.removed(bssid)
+					}
+				}
+			}
+		case <-settle:
+			c.refresh()
+			settle = nil
+		}
+	}
+}
+
+// refresh replaces the table with the current scan results.
+func (c *ScanCache) refresh() []error {
+	results, errs := c.conn.ScanResults()
+	if len(errs) > 0 && len(results) == 0 {
+		return errs
+	}
+
+	now := time.Now()
+	var changes []ScanChange
+
+	c.lock.Lock()
+	seen := make(map[string]bool, len(results))
+	for _, r := range results {
+		key := r.BSSID().String()
+		seen[key] = true
+		if ch, ok := c.update(key, r, now); ok {
+			changes = append(changes, ch)
+		}
+	}
+	for key, e := range c.entries {
+		if !seen[key] {
+			delete(c.entries, key)
+			changes = append(changes, ScanChange{Kind: BSSRemoved, BSS: *e})
+		}
+	}
+	c.lock.Unlock()
+
+	for _, ch := range changes {
+		c.publish(ch)
+	}
+	return errs
+}
+
+// removed removes a BSS reported by BSS-REMOVED.
+func (c *ScanCache) removed(bssid net.HardwareAddr) {
+	key := bssid.String()
+
+	c.lock.Lock()
+	e, ok := c.entries[key]
+	delete(c.entries, key)
+	c.lock.Unlock()
+
+	if ok {
+		c.publish(ScanChange{Kind: BSSRemoved, BSS: *e})
+	}
+}
+
+// update adds or updates an entry, returning the resulting change if any.
+// The caller must hold c.lock.
+func (c *ScanCache) update(key string, r ScanResult, now time.Time) (ScanChange, bool) {
+	e, ok := c.entries[key]
+	if !ok {
+		e = &CachedBSS{ScanResult: r, FirstSeen: now, LastSeen: now}
+		c.entries[key] = e
+		return ScanChange{Kind: BSSAdded, BSS: *e}, true
+	}
+
+	prev := e.ScanResult.RSSI()
+	e.ScanResult, e.LastSeen = r, now
+	if r.RSSI() != prev {
+		return ScanChange{Kind: BSSSignalChanged, BSS: *e, PreviousRSSI: prev}, true
+	}
+	return ScanChange{}, false
+}
+
+// Get returns the entry for a BSS.
+func (c *ScanCache) Get(bssid net.HardwareAddr) (CachedBSS, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	e, ok := c.entries[bssid.String()]
+	if !ok {
+		return CachedBSS{}, false
+	}
+	return *e, true
+}
+
+// Entries returns all the entries, strongest signal first.
+func (c *ScanCache) Entries() []CachedBSS {
+	c.lock.Lock()
+	res := make([]CachedBSS, 0, len(c.entries))
+	for _, e := range c.entries {
+		res = append(res, *e)
+	}
+	c.lock.Unlock()
+
+	sort.Slice(res, func(i, j int) bool {
+		if ri, rj := res[i].ScanResult.RSSI(), res[j].ScanResult.RSSI(); ri != rj {
+			return ri > rj
+		}
+		return res[i].ScanResult.BSSID().String() < res[j].ScanResult.BSSID().String()
+	})
+	return res
+}
+
+// Results returns the cached scan results, strongest signal first.
+func (c *ScanCache) Results() ScanResults {
+	entries := c.Entries()
+	res := make(ScanResults, len(entries))
+	for i, e := range entries {
+		res[i] = e.ScanResult
+	}
+	return res
+}
+
+// publish delivers a change to every watcher without blocking.
+func (c *ScanCache) publish(ch ScanChange) {
+	c.watchLock.Lock()
+	defer c.watchLock.Unlock()
+
+	for w := range c.watchers {
+		select {
+		case w <- ch:
+		default:
+		}
+	}
+}
+
+// Watch returns a channel which receives the changes of the cache.  The
+// returned function cancels the watch and closes the channel.  Changes are
+// dropped for watchers which don't keep up.
+func (c *ScanCache) Watch() (<-chan ScanChange, func()) {
+	w := make(chan ScanChange, subscriberQueueLen)
+
+	c.watchLock.Lock()
+	c.watchers[w] = struct{}{}
+	c.watchLock.Unlock()
+
+	var once sync.Once
+	return w, func() {
+		once.Do(func() {
+			c.watchLock.Lock()
+			if _, ok := c.watchers[w]; ok {
+				delete(c.watchers, w)
+				close(w)
+			}
+			c.watchLock.Unlock()
+		})
+	}
+}
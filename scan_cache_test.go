@@ -0,0 +1,146 @@
+package wpasupplicant_test
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-laeo/wpasupplicant"
+	"github.com/go-laeo/wpasupplicant/wpatest"
+)
+
+// waitChange waits for a change of the given kind.
+func waitChange(t *testing.T, changes <-chan wpasupplicant.ScanChange, kind wpasupplicant.ScanChangeKind) wpasupplicant.ScanChange {
+	t.Helper()
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case ch := <-changes:
+			if ch.Kind == kind {
+				return ch
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s", kind)
+		}
+	}
+}
+
+func TestScanCache(t *testing.T) {
+	srv, conn := connect(t)
+	srv.SetBSSs(
+		wpatest.BSS{BSSID: "02:00:00:00:01:00", SSID: "home", Frequency: 2412, Signal: -40, Flags: "[WPA2-PSK-CCMP][ESS]"},
+		wpatest.BSS{BSSID: "02:00:00:00:02:00", SSID: "cafe", Frequency: 5180, Signal: -70, Flags: "[ESS]"},
+	)
+
+	cache, err := wpasupplicant.NewScanCache(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+
+	if r := cache.Results(); len(r) != 2 || r[0].SSID() != "home" {
+		t.Fatalf("got %+v", r)
+	}
+	home, _ := net.ParseMAC("02:00:00:00:01:00")
+	first, ok := cache.Get(home)
+	if !ok || first.FirstSeen.IsZero() || first.LastSeen != first.FirstSeen {
+		t.Errorf("got %+v", first)
+	}
+
+	changes, unwatch := cache.Watch()
+	defer unwatch()
+
+	srv.AddBSS(wpatest.BSS{BSSID: "02:00:00:00:03:00", SSID: "office", Frequency: 5975, Signal: -55, Flags: "[WPA2-SAE-CCMP][ESS]"})
+	srv.Emit("CTRL-EVENT-BSS-ADDED 2 02:00:00:00:03:00")
+	ch := waitChange(t, changes, wpasupplicant.BSSAdded)
+	if ch.BSS.ScanResult.SSID() != "office" || ch.BSS.ScanResult.RSSI() != -55 {
+		t.Errorf("got %+v", ch.BSS.ScanResult)
+	}
+
+	srv.AddBSS(wpatest.BSS{BSSID: "02:00:00:00:01:00", SSID: "home", Frequency: 2412, Signal: -60, Flags: "[WPA2-PSK-CCMP][ESS]"})
+	srv.RemoveBSS("02:00:00:00:02:00")
+	srv.Emit("CTRL-EVENT-SCAN-RESULTS ")
+	ch = waitChange(t, changes, wpasupplicant.BSSSignalChanged)
+	if ch.PreviousRSSI != -40 || ch.BSS.ScanResult.RSSI() != -60 || !ch.BSS.LastSeen.After(first.LastSeen) || ch.BSS.FirstSeen != first.FirstSeen {
+		t.Errorf("got %+v", ch)
+	}
+	if ch := waitChange(t, changes, wpasupplicant.BSSRemoved); ch.BSS.ScanResult.SSID() != "cafe" {
+		t.Errorf("got %+v", ch.BSS.ScanResult)
+	}
+
+	srv.RemoveBSS("02:00:00:00:03:00")
+	srv.Emit("CTRL-EVENT-BSS-REMOVED 2 02:00:00:00:03:00")
+	if ch := waitChange(t, changes, wpasupplicant.BSSRemoved); ch.BSS.ScanResult.SSID() != "office" {
+		t.Errorf("got %+v", ch.BSS.ScanResult)
+	}
+	if r := cache.Results(); len(r) != 1 {
+		t.Errorf("got %d results", len(r))
+	}
+}
+
+func TestScanCacheBurst(t *testing.T) {
+	srv, conn := connect(t)
+	srv.SetBSSs(
+		wpatest.BSS{BSSID: "02:00:00:00:01:00", SSID: "home", Frequency: 2412, Signal: -40, Flags: "[ESS]"},
+		wpatest.BSS{BSSID: "02:00:00:00:02:00", SSID: "cafe", Frequency: 5180, Signal: -70, Flags: "[ESS]"},
+	)
+
+	cache, err := wpasupplicant.NewScanCache(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+
+	// A busy site: many more events than a subscription buffers.
+	const n = 100
+	var bss []wpatest.BSS
+	for i := 0; i < n; i++ {
+		bss = append(bss, wpatest.BSS{BSSID: fmt.Sprintf("02:00:00:01:%02x:00", i), SSID: fmt.Sprintf("ap%d", i), Frequency: 2437, Signal: -50, Flags: "[ESS]"})
+	}
+	srv.SetBSSs(bss...)
+	for i, b := range bss {
+		srv.Emit(fmt.Sprintf("CTRL-EVENT-BSS-ADDED %d %s", i+2, b.BSSID))
+	}
+	srv.Emit("CTRL-EVENT-SCAN-RESULTS ")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		r := cache.Results()
+		if len(r) == n {
+			if _, ok := cache.Get(r[0].BSSID()); !ok {
+				t.Errorf("%s missing", r[0].BSSID())
+			}
+			home, _ := net.ParseMAC("02:00:00:00:01:00")
+			if _, ok := cache.Get(home); ok {
+				t.Error("removed BSS still cached")
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d results, expect %d", len(r), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestScanCacheAddedWithoutResults(t *testing.T) {
+	srv, conn := connect(t)
+
+	cache, err := wpasupplicant.NewScanCache(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+
+	changes, unwatch := cache.Watch()
+	defer unwatch()
+
+	// A BSS added outside of a scan is picked up without SCAN-RESULTS.
+	srv.AddBSS(wpatest.BSS{BSSID: "02:00:00:00:03:00", SSID: "office", Frequency: 5975, Signal: -55, Flags: "[ESS]"})
+	srv.Emit("CTRL-EVENT-BSS-ADDED 0 02:00:00:00:03:00")
+	if ch := waitChange(t, changes, wpasupplicant.BSSAdded); ch.BSS.ScanResult.SSID() != "office" {
+		t.Errorf("got %+v", ch.BSS.ScanResult)
+	}
+}
@@ -1,6 +1,9 @@
 package wpasupplicant
 
-import "net"
+import (
+	"encoding/json"
+	"net"
+)
 
 // ScanResult is a scanned BSS.
 type ScanResult interface {
@@ -13,6 +16,10 @@ type ScanResult interface {
 	// Frequency is the frequency, in Mhz, of the BSS.
 	Frequency() int
 
+	// Channel is the primary channel of the BSS.  Its width is unknown:
+	// see Conn.BSS.
+	Channel() Channel
+
 	// RSSI is the received signal strength, in dB, of the BSS.
 	RSSI() int
 
@@ -20,6 +27,10 @@ type ScanResult interface {
 	// wpa_supplicant SCAN_RESULTS command.  Future versions of this code
 	// will parse these into something more meaningful.
 	Flags() []string
+
+	// Security is the list of security configurations advertised by the
+	// BSS, parsed from Flags.  It is empty for open networks.
+	Security() []Security
 }
 
 // scanResult is a package-private implementation of ScanResult.
@@ -29,6 +40,7 @@ type scanResult struct {
 	frequency int
 	rssi      int
 	flags     []string
+	security  []Security
 }
 
 func (r *scanResult) BSSID() net.HardwareAddr { return r.bssid }
@@ -36,3 +48,77 @@ func (r *scanResult) SSID() string            { return r.ssid }
 func (r *scanResult) Frequency() int          { return r.frequency }
 func (r *scanResult) RSSI() int               { return r.rssi }
 func (r *scanResult) Flags() []string         { return r.flags }
+func (r *scanResult) Security() []Security    { return r.security }
+
+func (r *scanResult) Channel() Channel {
+	ch, _ := ChannelFromFrequency(r.frequency)
+	return ch
+}
+
+// scanResultJSON is the JSON representation of a scanResult.
+type scanResultJSON struct {
+	BSSID     string     `json:"bssid"`
+	SSID      string     `json:"ssid"`
+	Frequency int        `json:"frequency"`
+	RSSI      int        `json:"rssi"`
+	Flags     []string   `json:"flags"`
+	Security  []Security `json:"security"`
+}
+
+// MarshalJSON encodes the scan result as an object with the fields bssid
+// (formatted as "aa:bb:cc:dd:ee:ff"), ssid, frequency, rssi, flags and
+// security.
+func (r *scanResult) MarshalJSON() ([]byte, error) {
+	v := scanResultJSON{
+		BSSID:     r.bssid.String(),
+		SSID:      r.ssid,
+		Frequency: r.frequency,
+		RSSI:      r.rssi,
+		Flags:     r.flags,
+		Security:  r.security,
+	}
+	if v.Flags == nil {
+		v.Flags = []string{}
+	}
+	if v.Security == nil {
+		v.Security = []Security{}
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes the format written by MarshalJSON.  The security
+// field is recomputed from the flags.
+func (r *scanResult) UnmarshalJSON(data []byte) error {
+	var v scanResultJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	var bssid net.HardwareAddr
+	if v.BSSID != "" {
+		var err error
+		if bssid, err = net.ParseMAC(v.BSSID); err != nil {
+			return err
+		}
+	}
+
+	*r = scanResult{
+		bssid:     bssid,
+		ssid:      v.SSID,
+		frequency: v.Frequency,
+		rssi:      v.RSSI,
+		flags:     v.Flags,
+		security:  parseSecurityFlags(v.Flags),
+	}
+	return nil
+}
+
+// UnmarshalScanResult decodes a ScanResult previously encoded with
+// json.Marshal.
+func UnmarshalScanResult(data []byte) (ScanResult, error) {
+	r := &scanResult{}
+	if err := r.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
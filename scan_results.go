@@ -0,0 +1,165 @@
+package wpasupplicant
+
+import (
+	"sort"
+	"strings"
+)
+
+// ScanResults is a list of scanned BSSs, as returned by Conn.ScanResults.
+// It has one entry per BSS: see GroupBySSID for a per-network view.  The
+// Filter methods return new lists, leaving the original unchanged.
+type ScanResults []ScanResult
+
+// FilterBand returns the results in the given band.
+func (rs ScanResults) FilterBand(band Band) ScanResults {
+	return rs.filter(func(r ScanResult) bool {
+		return r.Channel().Band == band
+	})
+}
+
+// FilterMinRSSI returns the results with a signal of at least rssi dBm.
+func (rs ScanResults) FilterMinRSSI(rssi int) ScanResults {
+	return rs.filter(func(r ScanResult) bool {
+		return r.RSSI() >= rssi
+	})
+}
+
+// FilterSecurity returns the results offering any of the key management
+// suites in k.  KEY_MGMT_NONE matches open networks.
+func (rs ScanResults) FilterSecurity(k KeyMgmt) ScanResults {
+	return rs.filter(func(r ScanResult) bool {
+		sec := r.Security()
+		if len(sec) == 0 {
+			return k&KEY_MGMT_NONE != 0
+		}
+		for _, s := range sec {
+			if s.KeyMgmt&k != 0 {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// FilterHidden returns the results of hidden networks, which don't
+// broadcast their SSID, if hidden is true, or the others if it's false.
+func (rs ScanResults) FilterHidden(hidden bool) ScanResults {
+	return rs.filter(func(r ScanResult) bool {
+		return HiddenSSID(r.SSID()) == hidden
+	})
+}
+
+func (rs ScanResults) filter(keep func(ScanResult) bool) ScanResults {
+	var res ScanResults
+	for _, r := range rs {
+		if keep(r) {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// SortBySignal sorts the results in place, strongest signal first.
+func (rs ScanResults) SortBySignal() {
+	sort.SliceStable(rs, func(i, j int) bool {
+		return rs[i].RSSI() > rs[j].RSSI()
+	})
+}
+
+// HiddenSSID returns true for the SSIDs reported for hidden networks: empty,
+// or made of NUL bytes (shown as "\x00" by wpa_supplicant).
+func HiddenSSID(ssid string) bool {
+	return strings.Trim(strings.ReplaceAll(ssid, `\x00`, ""), "\x00") == ""
+}
+
+// SignalQuality converts a signal level in dBm to a percentage, linearly
+// from -100 dBm (0%) to -50 dBm (100%).
+func SignalQuality(dBm int) int {
+	switch {
+	case dBm <= -100:
+		return 0
+	case dBm >= -50:
+		return 100
+	}
+	return 2 * (dBm + 100)
+}
+
+// ScanNetwork is a network seen in a scan: the BSSs with the same SSID.
+type ScanNetwork struct {
+	// SSID is the network name, empty for hidden networks.
+	SSID string
+
+	// Hidden is true for a hidden network.  Hidden networks can't be told
+	// apart, so each hidden BSS is a network of its own.
+	Hidden bool
+
+	// BSSs are the access points of the network, strongest first.
+	BSSs ScanResults
+
+	// Bands are the bands the network is available in, in increasing
+	// frequency.
+	Bands []Band
+
+	// KeyMgmt is the set of key management suites offered by any of the
+	// BSSs, and Open is true if any BSS is open.
+	KeyMgmt KeyMgmt
+	Open    bool
+}
+
+// Best returns the BSS with the strongest signal.
+func (n *ScanNetwork) Best() ScanResult {
+	return n.BSSs[0]
+}
+
+// RSSI returns the signal level of the best BSS, in dBm.
+func (n *ScanNetwork) RSSI() int {
+	return n.Best().RSSI()
+}
+
+// GroupBySSID groups the results into networks, strongest first, as they
+// are usually presented to users.
+func (rs ScanResults) GroupBySSID() []*ScanNetwork {
+	sorted := append(ScanResults(nil), rs...)
+	sorted.SortBySignal()
+
+	var networks []*ScanNetwork
+	bySSID := map[string]*ScanNetwork{}
+	for _, r := range sorted {
+		hidden := HiddenSSID(r.SSID())
+
+		n := bySSID[r.SSID()]
+		if n == nil || hidden {
+			n = &ScanNetwork{Hidden: hidden}
+			if !hidden {
+				n.SSID = r.SSID()
+				bySSID[n.SSID] = n
+			}
+			networks = append(networks, n)
+		}
+		n.add(r)
+	}
+
+	return networks
+}
+
+// add adds a BSS to the network.  BSSs must be added strongest first.
+func (n *ScanNetwork) add(r ScanResult) {
+	n.BSSs = append(n.BSSs, r)
+
+	if band := r.Channel().Band; band != BandUnknown {
+		i := sort.Search(len(n.Bands), func(i int) bool { return n.Bands[i] >= band })
+		if i == len(n.Bands) || n.Bands[i] != band {
+			n.Bands = append(n.Bands, 0)
+			copy(n.Bands[i+1:], n.Bands[i:])
+			n.Bands[i] = band
+		}
+	}
+
+	sec := r.Security()
+	if len(sec) == 0 {
+		n.Open = true
+	}
+	for _, s := range sec {
+		n.KeyMgmt |= s.KeyMgmt
+	}
+}
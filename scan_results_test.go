@@ -0,0 +1,70 @@
+package wpasupplicant_test
+
+import (
+	"testing"
+
+	"github.com/go-laeo/wpasupplicant"
+	"github.com/go-laeo/wpasupplicant/wpatest"
+)
+
+func TestGroupBySSID(t *testing.T) {
+	srv, conn := connect(t)
+	srv.SetBSSs(
+		wpatest.BSS{BSSID: "02:00:00:00:01:00", SSID: "mesh", Frequency: 2412, Signal: -70, Flags: "[WPA2-PSK-CCMP][ESS]"},
+		wpatest.BSS{BSSID: "02:00:00:00:01:01", SSID: "mesh", Frequency: 5180, Signal: -50, Flags: "[WPA2-PSK+SAE-CCMP][ESS]"},
+		wpatest.BSS{BSSID: "02:00:00:00:01:02", SSID: "mesh", Frequency: 2437, Signal: -80, Flags: "[WPA2-PSK-CCMP][ESS]"},
+		wpatest.BSS{BSSID: "02:00:00:00:02:00", SSID: "cafe", Frequency: 2462, Signal: -60, Flags: "[ESS]"},
+		wpatest.BSS{BSSID: "02:00:00:00:03:00", SSID: "", Frequency: 5200, Signal: -65, Flags: "[WPA2-PSK-CCMP][ESS]"},
+		wpatest.BSS{BSSID: "02:00:00:00:04:00", SSID: `\x00\x00\x00`, Frequency: 2412, Signal: -90, Flags: "[ESS]"},
+	)
+
+	results, errs := conn.ScanResults()
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	networks := results.GroupBySSID()
+	if len(networks) != 4 {
+		t.Fatalf("got %d networks", len(networks))
+	}
+
+	mesh := networks[0]
+	if mesh.SSID != "mesh" || len(mesh.BSSs) != 3 || mesh.Best().BSSID().String() != "02:00:00:00:01:01" || mesh.RSSI() != -50 {
+		t.Errorf("got %+v", mesh)
+	}
+	if len(mesh.Bands) != 2 || mesh.Bands[0] != wpasupplicant.Band2GHz || mesh.Bands[1] != wpasupplicant.Band5GHz {
+		t.Errorf("got bands %v", mesh.Bands)
+	}
+	if mesh.KeyMgmt != wpasupplicant.PSK|wpasupplicant.SAE || mesh.Open {
+		t.Errorf("got security %s, open %v", mesh.KeyMgmt, mesh.Open)
+	}
+	if cafe := networks[1]; cafe.SSID != "cafe" || !cafe.Open || cafe.KeyMgmt != 0 {
+		t.Errorf("got %+v", cafe)
+	}
+	if !networks[2].Hidden || !networks[3].Hidden || networks[3].SSID != "" {
+		t.Errorf("got %+v, %+v", networks[2], networks[3])
+	}
+
+	if r := results.FilterHidden(false).FilterMinRSSI(-70); len(r) != 3 {
+		t.Errorf("got %d results", len(r))
+	}
+	if r := results.FilterSecurity(wpasupplicant.SAE); len(r) != 1 {
+		t.Errorf("got %d SAE results", len(r))
+	}
+	if r := results.FilterSecurity(wpasupplicant.KEY_MGMT_NONE); len(r) != 2 {
+		t.Errorf("got %d open results", len(r))
+	}
+
+	results.SortBySignal()
+	if results[0].RSSI() != -50 || results[len(results)-1].RSSI() != -90 {
+		t.Errorf("not sorted: %d .. %d", results[0].RSSI(), results[len(results)-1].RSSI())
+	}
+}
+
+func TestSignalQuality(t *testing.T) {
+	for dBm, q := range map[int]int{-30: 100, -50: 100, -67: 66, -100: 0, -110: 0} {
+		if got := wpasupplicant.SignalQuality(dBm); got != q {
+			t.Errorf("%d dBm: got %d%%, expect %d%%", dBm, got, q)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package wpasupplicant
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventScanFailed is the name of CTRL-EVENT-SCAN-FAILED events, sent when a
+// scan couldn't be started by the driver.
+const EventScanFailed = "SCAN-FAILED"
+
+// Delays used by a Scanner.
+const (
+	// scanSuppressedDelay is how long scans are postponed while a
+	// connection attempt is in progress.
+	scanSuppressedDelay = 500 * time.Millisecond
+
+	// scanResultsTimeout is how long a Scanner waits for the results of
+	// a scan.
+	scanResultsTimeout = 15 * time.Second
+
+	// scanRetryMin and scanRetryMax bound the delay after failed scans,
+	// which doubles with each consecutive failure.
+	scanRetryMin = time.Second
+	scanRetryMax = time.Minute
+)
+
+// ScanState is what a ScanPolicy knows when scheduling the next scan.
+type ScanState struct {
+	// Connected is true if wpa_supplicant was connected during the last
+	// scan.
+	Connected bool
+
+	// RSSI is the signal level, in dBm, of the current BSS in the last
+	// scan results, or 0 if it wasn't found.
+	RSSI int
+
+	// Unchanged is the number of consecutive scans which found the same
+	// BSSs as the one before.
+	Unchanged int
+
+	// Failed is true if the last scan failed, and Failures is the number
+	// of consecutive failed scans.
+	Failed   bool
+	Failures int
+}
+
+// retryDelay returns the delay after a failed scan, doubling from
+// scanRetryMin up to scanRetryMax with each consecutive failure, or 0 if
+// the last scan didn't fail.
+func (s ScanState) retryDelay() time.Duration {
+	if !s.Failed {
+		return 0
+	}
+	d := scanRetryMin
+	for i := 1; i < s.Failures && d < scanRetryMax; i++ {
+		d *= 2
+	}
+	if d > scanRetryMax {
+		d = scanRetryMax
+	}
+	return d
+}
+
+// ScanPolicy decides how often a Scanner scans.
+type ScanPolicy interface {
+	// Next returns the delay until the next scan.  After a failed scan,
+	// the Scanner waits at least a second whatever the policy returns.
+	Next(ScanState) time.Duration
+}
+
+// FixedScanPolicy scans at a fixed interval, 30 seconds if zero.  Failed
+// scans are retried sooner, backing off up to the interval.
+type FixedScanPolicy struct {
+	Interval time.Duration
+}
+
+func (p FixedScanPolicy) Next(s ScanState) time.Duration {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	if s.Failed {
+		if r := s.retryDelay(); r < interval {
+			return r
+		}
+	}
+	return interval
+}
+
+// ExponentialScanPolicy scans every Min while the results change, doubling
+// the interval up to Max while they don't.  Min defaults to 10 seconds and
+// Max to 5 minutes.  Failed scans are retried with an exponential backoff.
+type ExponentialScanPolicy struct {
+	Min, Max time.Duration
+}
+
+func (p ExponentialScanPolicy) Next(s ScanState) time.Duration {
+	min, max := p.Min, p.Max
+	if min <= 0 {
+		min = 10 * time.Second
+	}
+	if max <= 0 {
+		max = 5 * time.Minute
+	}
+	if max < min {
+		max = min
+	}
+
+	if s.Failed {
+		return s.retryDelay()
+	}
+
+	d := min
+	for i := 0; i < s.Unchanged && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+// AdaptiveScanPolicy is an ExponentialScanPolicy which scans every Fast
+// while the signal of the current BSS is below Threshold dBm, when roaming
+// is likely.  Fast defaults to 5 seconds and Threshold to -70 dBm.
+type AdaptiveScanPolicy struct {
+	Min, Max  time.Duration
+	Fast      time.Duration
+	Threshold int
+}
+
+func (p AdaptiveScanPolicy) Next(s ScanState) time.Duration {
+	fast, threshold := p.Fast, p.Threshold
+	if fast <= 0 {
+		fast = 5 * time.Second
+	}
+	if threshold == 0 {
+		threshold = -70
+	}
+
+	if !s.Failed && s.Connected && s.RSSI != 0 && s.RSSI < threshold {
+		return fast
+	}
+	return ExponentialScanPolicy{Min: p.Min, Max: p.Max}.Next(s)
+}
+
+// Scanner scans periodically, including while connected, unlike
+// wpa_supplicant's autoscan.  Scans are postponed while a connection attempt
+// is in progress, and a scan started by someone else (FAIL-BUSY) is waited
+// for instead.
+type Scanner struct {
+	conn    Conn
+	policy  ScanPolicy
+	cancel  context.CancelFunc
+	done    chan struct{}
+	results chan ScanResults
+
+	lock  sync.Mutex
+	state ScanState
+}
+
+// NewScanner starts scanning with conn according to policy, until ctx is
+// done or Close is called.
+func NewScanner(ctx context.Context, conn Conn, policy ScanPolicy) *Scanner {
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Scanner{
+		conn:    conn,
+		policy:  policy,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		results: make(chan ScanResults, 1),
+	}
+
+	go s.run(ctx)
+
+	return s
+}
+
+// Results returns a channel which receives the results of each scan.  Only
+// the latest results are kept if the receiver doesn't keep up.  The channel
+// is closed when the scanner stops.
+func (s *Scanner) Results() <-chan ScanResults {
+	return s.results
+}
+
+// State returns the state after the last scan.
+func (s *Scanner) State() ScanState {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.state
+}
+
+// Close stops scanning.
+func (s *Scanner) Close() error {
+	s.cancel()
+	<-s.done
+	return nil
+}
+
+// run scans until ctx is done.
+func (s *Scanner) run(ctx context.Context) {
+	defer close(s.done)
+	defer close(s.results)
+
+	events, unsubscribe := s.conn.Subscribe()
+	defer unsubscribe()
+
+	var prev map[string]bool
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		status, err := s.conn.Status()
+		if err == nil && connecting(status.WPAState()) {
+			timer.Reset(scanSuppressedDelay)
+			continue
+		}
+
+		results, err := scanWait(ctx, s.conn, events)
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.lock.Lock()
+		state := ScanState{Failed: err != nil}
+		if err != nil {
+			state.Failures = s.state.Failures + 1
+		} else {
+			seen := make(map[string]bool, len(results))
+			for _, r := range results {
+				seen[r.BSSID().String()] = true
+			}
+			if prev != nil && sameKeys(prev, seen) {
+				state.Unchanged = s.state.Unchanged + 1
+			}
+			prev = seen
+
+			if status != nil && status.WPAState() == "COMPLETED" {
+				state.Connected = true
+				for _, r := range results {
+					if strings.EqualFold(r.BSSID().String(), status.BSSID()) {
+						state.RSSI = r.RSSI()
+					}
+				}
+			}
+		}
+		s.state = state
+		s.lock.Unlock()
+
+		if err == nil {
+			s.deliver(results)
+		}
+		d := s.policy.Next(state)
+		if state.Failed && d < scanRetryMin {
+			d = scanRetryMin
+		}
+		timer.Reset(d)
+	}
+}
+
+// scanWait scans with conn and waits for the results.  events must be a
+// subscription of conn.  If a scan is already in progress, its results are
+// returned.
+func scanWait(ctx context.Context, conn Conn, events <-chan WPAEvent) (ScanResults, error) {
+	// Forget the events received since the last scan.
+	for drained := false; !drained; {
+		select {
+		case <-events:
+		default:
+			drained = true
+		}
+	}
+
+	if err := conn.Scan(); err != nil && !errors.Is(err, ErrBusy) {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, scanResultsTimeout)
+	defer cancel()
+	err := await(ctx, events, func(e WPAEvent) (bool, error) {
+		switch e.Event {
+		case EventScanResults:
+			return true, nil
+		case EventScanFailed:
+			return true, errors.New("scan failed")
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results, errs := conn.ScanResults()
+	if len(errs) > 0 && len(results) == 0 {
+		return nil, errs[0]
+	}
+	return results, nil
+}
+
+// deliver sends results, replacing any which weren't received.
+func (s *Scanner) deliver(results ScanResults) {
+	for {
+		select {
+		case s.results <- results:
+			return
+		default:
+		}
+		select {
+		case <-s.results:
+		default:
+		}
+	}
+}
+
+// connecting returns true for the states of a connection attempt.
+func connecting(wpaState string) bool {
+	switch wpaState {
+	case "AUTHENTICATING", "ASSOCIATING", "ASSOCIATED", "4WAY_HANDSHAKE", "GROUP_HANDSHAKE":
+		return true
+	}
+	return false
+}
+
+func sameKeys(a, b map[string]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k := range a {
+		if !b[k] {
+			return false
+		}
+	}
+	return true
+}
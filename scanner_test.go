@@ -0,0 +1,133 @@
+package wpasupplicant_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-laeo/wpasupplicant"
+	"github.com/go-laeo/wpasupplicant/wpatest"
+)
+
+func TestScanPolicies(t *testing.T) {
+	exp := wpasupplicant.ExponentialScanPolicy{Min: 10 * time.Second, Max: 60 * time.Second}
+	adaptive := wpasupplicant.AdaptiveScanPolicy{Min: 10 * time.Second, Max: 60 * time.Second, Fast: 5 * time.Second, Threshold: -75}
+
+	tests := []struct {
+		policy wpasupplicant.ScanPolicy
+		state  wpasupplicant.ScanState
+		expect time.Duration
+	}{
+		{wpasupplicant.FixedScanPolicy{Interval: time.Minute}, wpasupplicant.ScanState{Unchanged: 5}, time.Minute},
+		{exp, wpasupplicant.ScanState{}, 10 * time.Second},
+		{exp, wpasupplicant.ScanState{Unchanged: 2}, 40 * time.Second},
+		{exp, wpasupplicant.ScanState{Unchanged: 10}, 60 * time.Second},
+		{adaptive, wpasupplicant.ScanState{Connected: true, RSSI: -80, Unchanged: 3}, 5 * time.Second},
+		{adaptive, wpasupplicant.ScanState{Connected: true, RSSI: -50, Unchanged: 1}, 20 * time.Second},
+		{adaptive, wpasupplicant.ScanState{RSSI: -80}, 10 * time.Second},
+
+		// Zero fields take the defaults.
+		{wpasupplicant.FixedScanPolicy{}, wpasupplicant.ScanState{}, 30 * time.Second},
+		{wpasupplicant.ExponentialScanPolicy{}, wpasupplicant.ScanState{Unchanged: 10}, 5 * time.Minute},
+		{wpasupplicant.AdaptiveScanPolicy{}, wpasupplicant.ScanState{Connected: true, RSSI: -72}, 5 * time.Second},
+
+		// Failed scans back off.
+		{wpasupplicant.FixedScanPolicy{Interval: time.Minute}, wpasupplicant.ScanState{Failed: true, Failures: 1}, time.Second},
+		{wpasupplicant.FixedScanPolicy{Interval: 3 * time.Second}, wpasupplicant.ScanState{Failed: true, Failures: 5}, 3 * time.Second},
+		{exp, wpasupplicant.ScanState{Unchanged: 4, Failed: true, Failures: 3}, 4 * time.Second},
+		{adaptive, wpasupplicant.ScanState{Connected: true, RSSI: -80, Failed: true, Failures: 10}, time.Minute},
+	}
+
+	for i, test := range tests {
+		if d := test.policy.Next(test.state); d != test.expect {
+			t.Errorf("%d: got %s, expect %s", i, d, test.expect)
+		}
+	}
+}
+
+func TestScanner(t *testing.T) {
+	srv, conn := connect(t)
+	srv.SetBSSs(wpatest.BSS{BSSID: "02:00:00:00:01:00", SSID: "home", Frequency: 2412, Signal: -40, Flags: "[ESS]"})
+
+	// Scans are suppressed while associating.
+	var mu sync.Mutex
+	state := "ASSOCIATING"
+	srv.Handle("STATUS", func(string) (string, []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		return "bssid=02:00:00:00:01:00\nwpa_state=" + state + "\n", nil
+	})
+
+	// The first scan finds one already in progress.
+	busy := true
+	srv.Handle("SCAN", func(string) (string, []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if busy {
+			busy = false
+			return "FAIL-BUSY\n", []string{"CTRL-EVENT-SCAN-RESULTS "}
+		}
+		return "OK\n", []string{"CTRL-EVENT-SCAN-RESULTS "}
+	})
+
+	scanner := wpasupplicant.NewScanner(context.Background(), conn, wpasupplicant.FixedScanPolicy{Interval: 10 * time.Millisecond})
+	defer scanner.Close()
+
+	time.Sleep(100 * time.Millisecond)
+	for _, cmd := range srv.Commands() {
+		if cmd == "SCAN" {
+			t.Fatal("scanned while associating")
+		}
+	}
+	mu.Lock()
+	state = "COMPLETED"
+	mu.Unlock()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case results := <-scanner.Results():
+			if len(results) != 1 {
+				t.Errorf("got %+v", results)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for results")
+		}
+	}
+	if s := scanner.State(); !s.Connected || s.RSSI != -40 || s.Unchanged == 0 {
+		t.Errorf("got %+v", s)
+	}
+
+	// The results channel is closed once the scanner stops.
+	scanner.Close()
+	for range scanner.Results() {
+	}
+}
+
+func TestScannerFailures(t *testing.T) {
+	srv, conn := connect(t)
+	srv.Handle("STATUS", func(string) (string, []string) {
+		return "wpa_state=DISCONNECTED\n", nil
+	})
+	srv.Handle("SCAN", func(string) (string, []string) {
+		return "FAIL\n", nil
+	})
+
+	// A zero-value policy doesn't retry a failing scan immediately.
+	scanner := wpasupplicant.NewScanner(context.Background(), conn, wpasupplicant.FixedScanPolicy{})
+	defer scanner.Close()
+
+	time.Sleep(300 * time.Millisecond)
+	scans := 0
+	for _, cmd := range srv.Commands() {
+		if cmd == "SCAN" {
+			scans++
+		}
+	}
+	if scans != 1 {
+		t.Errorf("sent %d scans, expect 1", scans)
+	}
+	if s := scanner.State(); !s.Failed || s.Failures != 1 {
+		t.Errorf("got %+v", s)
+	}
+}
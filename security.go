@@ -0,0 +1,301 @@
+package wpasupplicant
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// keyMgmtNames maps each KeyMgmt bit to the name used for it in the
+// key_mgmt network variable.
+var keyMgmtNames = []struct {
+	k    KeyMgmt
+	name string
+}{
+	{IEEE8021X, "WPA-EAP"},
+	{PSK, "WPA-PSK"},
+	{KEY_MGMT_NONE, "NONE"},
+	{IEEE8021X_NO_WPA, "IEEE8021X"},
+	{WPA_NONE, "WPA-NONE"},
+	{FT_IEEE8021X, "FT-EAP"},
+	{FT_PSK, "FT-PSK"},
+	{IEEE8021X_SHA256, "WPA-EAP-SHA256"},
+	{PSK_SHA256, "WPA-PSK-SHA256"},
+	{WPS, "WPS"},
+	{SAE, "SAE"},
+	{FT_SAE, "FT-SAE"},
+	{WAPI_PSK, "WAPI-PSK"},
+	{WAPI_CERT, "WAPI-CERT"},
+	{CCKM, "CCKM"},
+	{OSEN, "OSEN"},
+	{IEEE8021X_SUITE_B, "WPA-EAP-SUITE-B"},
+	{IEEE8021X_SUITE_B_192, "WPA-EAP-SUITE-B-192"},
+	{FILS_SHA256, "FILS-SHA256"},
+	{FILS_SHA384, "FILS-SHA384"},
+	{FT_FILS_SHA256, "FT-FILS-SHA256"},
+	{FT_FILS_SHA384, "FT-FILS-SHA384"},
+	{OWE, "OWE"},
+	{DPP, "DPP"},
+	{FT_IEEE8021X_SHA384, "FT-EAP-SHA384"},
+	{PASN, "PASN"},
+}
+
+// scanKeyMgmtNames maps the key management names used in SCAN_RESULTS flags
+// (which differ from the network variable names) to KeyMgmt bits.
+var scanKeyMgmtNames = map[string]KeyMgmt{
+	"EAP":             IEEE8021X,
+	"PSK":             PSK,
+	"None":            WPA_NONE,
+	"SAE":             SAE,
+	"FT/EAP":          FT_IEEE8021X,
+	"FT/PSK":          FT_PSK,
+	"FT/SAE":          FT_SAE,
+	"FT/EAP-SHA384":   FT_IEEE8021X_SHA384,
+	"EAP-SHA256":      IEEE8021X_SHA256,
+	"PSK-SHA256":      PSK_SHA256,
+	"EAP-SUITE-B":     IEEE8021X_SUITE_B,
+	"EAP-SUITE-B-192": IEEE8021X_SUITE_B_192,
+	"FILS-SHA256":     FILS_SHA256,
+	"FILS-SHA384":     FILS_SHA384,
+	"FT-FILS-SHA256":  FT_FILS_SHA256,
+	"FT-FILS-SHA384":  FT_FILS_SHA384,
+	"OWE":             OWE,
+	"DPP":             DPP,
+	"OSEN":            OSEN,
+	"PASN":            PASN,
+}
+
+// cipherNames maps each Cipher bit to the name used for it in the
+// pairwise, group and group_mgmt network variables.
+var cipherNames = []struct {
+	c    Cipher
+	name string
+}{
+	{CIPHER_NONE, "NONE"},
+	{WEP40, "WEP40"},
+	{WEP104, "WEP104"},
+	{TKIP, "TKIP"},
+	{CCMP, "CCMP"},
+	{AES_128_CMAC, "AES-128-CMAC"},
+	{GCMP, "GCMP"},
+	{SMS4, "SMS4"},
+	{GCMP_256, "GCMP-256"},
+	{CCMP_256, "CCMP-256"},
+	{BIP_GMAC_128, "BIP-GMAC-128"},
+	{BIP_GMAC_256, "BIP-GMAC-256"},
+	{BIP_CMAC_256, "BIP-CMAC-256"},
+	{GTK_NOT_USED, "GTK_NOT_USED"},
+}
+
+// Names returns the names of the key management suites in k, in the
+// order wpa_supplicant defines them.
+func (k KeyMgmt) Names() []string {
+	names := []string{}
+	for _, n := range keyMgmtNames {
+		if k&n.k != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return names
+}
+
+// String formats k the way the key_mgmt network variable expects it.
+func (k KeyMgmt) String() string {
+	return strings.Join(k.Names(), " ")
+}
+
+// ParseKeyMgmt parses a space separated list of key management names, as
+// used by the key_mgmt network variable.
+func ParseKeyMgmt(s string) (KeyMgmt, error) {
+	var k KeyMgmt
+	for _, name := range strings.Fields(s) {
+		found := false
+		for _, n := range keyMgmtNames {
+			if n.name == name {
+				k |= n.k
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown key management %q", name)
+		}
+	}
+	return k, nil
+}
+
+// MarshalJSON encodes k as an array of key management names.
+func (k KeyMgmt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(k.Names())
+}
+
+// UnmarshalJSON decodes an array of key management names.
+func (k *KeyMgmt) UnmarshalJSON(data []byte) error {
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return err
+	}
+
+	v, err := ParseKeyMgmt(strings.Join(names, " "))
+	if err != nil {
+		return err
+	}
+
+	*k = v
+	return nil
+}
+
+// Names returns the names of the ciphers in c, in the order wpa_supplicant
+// defines them.
+func (c Cipher) Names() []string {
+	names := []string{}
+	for _, n := range cipherNames {
+		if c&n.c != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return names
+}
+
+// String formats c the way the pairwise and group network variables expect
+// it.
+func (c Cipher) String() string {
+	return strings.Join(c.Names(), " ")
+}
+
+// ParseCipher parses a space separated list of cipher names, as used by the
+// pairwise and group network variables.
+func ParseCipher(s string) (Cipher, error) {
+	var c Cipher
+	for _, name := range strings.Fields(s) {
+		v, ok := lookupCipher(name)
+		if !ok {
+			return 0, fmt.Errorf("unknown cipher %q", name)
+		}
+		c |= v
+	}
+	return c, nil
+}
+
+// lookupCipher returns the Cipher bit for name.  The WEP ciphers are
+// accepted both with and without the dash wpa_supplicant uses in scan
+// results.
+func lookupCipher(name string) (Cipher, bool) {
+	switch name {
+	case "WEP-40":
+		return WEP40, true
+	case "WEP-104":
+		return WEP104, true
+	}
+
+	for _, n := range cipherNames {
+		if n.name == name {
+			return n.c, true
+		}
+	}
+	return 0, false
+}
+
+// MarshalJSON encodes c as an array of cipher names.
+func (c Cipher) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Names())
+}
+
+// UnmarshalJSON decodes an array of cipher names.
+func (c *Cipher) UnmarshalJSON(data []byte) error {
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return err
+	}
+
+	v, err := ParseCipher(strings.Join(names, " "))
+	if err != nil {
+		return err
+	}
+
+	*c = v
+	return nil
+}
+
+// Security is one of the security configurations advertised by a BSS, as
+// reported in the flags of the SCAN_RESULTS command, e.g.
+// "[WPA2-PSK+SAE-CCMP]".
+type Security struct {
+	// Proto is the protocol, e.g. "WPA", "WPA2", "RSN", "OSEN" or "WEP".
+	Proto string `json:"proto"`
+
+	// KeyMgmt is the set of key management suites offered.
+	KeyMgmt KeyMgmt `json:"key_mgmt"`
+
+	// Ciphers is the set of pairwise ciphers offered.
+	Ciphers Cipher `json:"ciphers"`
+
+	// Preauth is true if the BSS supports RSN pre-authentication.
+	Preauth bool `json:"preauth,omitempty"`
+}
+
+// parseSecurityFlags extracts the security configurations from the flags
+// of a scan result.  Flags which don't describe security are ignored.
+func parseSecurityFlags(flags []string) []Security {
+	var res []Security
+	for _, flag := range flags {
+		if flag == "WEP" {
+			res = append(res, Security{Proto: "WEP"})
+			continue
+		}
+
+		i := strings.IndexByte(flag, '-')
+		if i == -1 {
+			continue
+		}
+
+		sec := Security{Proto: flag[:i]}
+		switch sec.Proto {
+		case "WPA", "WPA2", "RSN", "OSEN":
+		default:
+			continue
+		}
+
+		rest := flag[i+1:]
+		if strings.HasSuffix(rest, "-preauth") {
+			sec.Preauth = true
+			rest = strings.TrimSuffix(rest, "-preauth")
+		}
+
+		// Key management and cipher names may themselves contain
+		// dashes, so look for the leftmost split which leaves only
+		// known ciphers on the right hand side.
+		kms := rest
+		for j := 0; j < len(rest); j++ {
+			if rest[j] != '-' {
+				continue
+			}
+			if c, ok := parseCipherList(rest[j+1:]); ok {
+				kms, sec.Ciphers = rest[:j], c
+				break
+			}
+		}
+
+		for _, km := range strings.Split(kms, "+") {
+			sec.KeyMgmt |= scanKeyMgmtNames[km]
+		}
+
+		res = append(res, sec)
+	}
+
+	return res
+}
+
+// parseCipherList parses a '+' separated list of ciphers, as used in scan
+// result flags.
+func parseCipherList(s string) (Cipher, bool) {
+	var c Cipher
+	for _, name := range strings.Split(s, "+") {
+		v, ok := lookupCipher(name)
+		if !ok {
+			return 0, false
+		}
+		c |= v
+	}
+	return c, true
+}
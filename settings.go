@@ -0,0 +1,258 @@
+package wpasupplicant
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Global settings known to Settings, which validates their values.
+const (
+	SettingCountry                = "country"
+	SettingAPScan                 = "ap_scan"
+	SettingFastReauth             = "fast_reauth"
+	SettingBSSExpirationAge       = "bss_expiration_age"
+	SettingBSSExpirationScanCount = "bss_expiration_scan_count"
+	SettingAutoscan               = "autoscan"
+	SettingPMF                    = "pmf"
+	SettingSAEGroups              = "sae_groups"
+	SettingMACAddr                = "mac_addr"
+	SettingRandAddrLifetime       = "rand_addr_lifetime"
+	SettingPreassocMACAddr        = "preassoc_mac_addr"
+	SettingOKC                    = "okc"
+	SettingBgscan                 = "bgscan"
+	SettingUpdateConfig           = "update_config"
+	SettingFilterSSIDs            = "filter_ssids"
+	SettingInterworking           = "interworking"
+	SettingHS20                   = "hs20"
+	SettingAutoInterworking       = "auto_interworking"
+	SettingWPSCredProcessing      = "wps_cred_processing"
+)
+
+// settingKind is the type of a setting's value.
+type settingKind int
+
+const (
+	settingString settingKind = iota
+	settingInt
+	settingBool
+)
+
+// setting describes a known setting.  Integers are limited to min..max;
+// strings are checked with validate, if set.
+type setting struct {
+	kind     settingKind
+	min, max int
+	validate func(string) error
+}
+
+// knownSettings are the settings whose values are validated.
+var knownSettings = map[string]setting{
+	SettingCountry:                {kind: settingString, validate: validateCountry},
+	SettingAPScan:                 {kind: settingInt, min: 0, max: 2},
+	SettingFastReauth:             {kind: settingBool},
+	SettingBSSExpirationAge:       {kind: settingInt, min: 10, max: 1 << 30},
+	SettingBSSExpirationScanCount: {kind: settingInt, min: 1, max: 1 << 30},
+	SettingAutoscan:               {kind: settingString, validate: validateModule},
+	SettingPMF:                    {kind: settingInt, min: 0, max: 2},
+	SettingSAEGroups:              {kind: settingString, validate: validateIntList},
+	SettingMACAddr:                {kind: settingInt, min: 0, max: 2},
+	SettingRandAddrLifetime:       {kind: settingInt, min: 0, max: 1 << 30},
+	SettingPreassocMACAddr:        {kind: settingInt, min: 0, max: 2},
+	SettingOKC:                    {kind: settingBool},
+	SettingBgscan:                 {kind: settingString, validate: validateModule},
+	SettingUpdateConfig:           {kind: settingBool},
+	SettingFilterSSIDs:            {kind: settingBool},
+	SettingInterworking:           {kind: settingBool},
+	SettingHS20:                   {kind: settingBool},
+	SettingAutoInterworking:       {kind: settingBool},
+	SettingWPSCredProcessing:      {kind: settingInt, min: 0, max: 2},
+}
+
+// moduleSpec matches an autoscan or bgscan module specification, e.g.
+// "exponential:3:300" or "simple:30:-45:300".
+var moduleSpec = regexp.MustCompile(`^[a-z]+(:[^:\s]*)*$`)
+
+func validateModule(v string) error {
+	if v != "" && !moduleSpec.MatchString(v) {
+		return errors.New("expected <module>[:<params>]")
+	}
+	return nil
+}
+
+func validateIntList(v string) error {
+	for _, f := range strings.Fields(v) {
+		if _, err := strconv.Atoi(f); err != nil {
+			return errors.New("expected space separated integers")
+		}
+	}
+	return nil
+}
+
+func validateCountry(v string) error {
+	if !ValidCountry(v) {
+		return ErrInvalidCountry
+	}
+	return nil
+}
+
+// SettingsSnapshot is a set of setting values saved by Settings.Snapshot.
+type SettingsSnapshot map[string]string
+
+// Settings reads and writes wpa_supplicant's global settings with GET and
+// SET.  Values of the known settings (see the Setting constants) are
+// validated before they are sent; other settings are passed as is.
+type Settings interface {
+	// Get returns the value of a setting.
+	Get(key string) (string, error)
+
+	// GetInt returns the value of an integer setting.
+	GetInt(key string) (int, error)
+
+	// GetBool returns the value of a boolean setting.
+	GetBool(key string) (bool, error)
+
+	// Set changes a setting.  value must be a string, int or bool.
+	Set(key string, value interface{}) error
+
+	// Snapshot saves the values of the given settings, or of all the
+	// known settings which wpa_supplicant reports if none are given.
+	Snapshot(keys ...string) (SettingsSnapshot, error)
+
+	// Restore sets the settings saved by Snapshot, e.g. to undo changes
+	// made by a test.
+	Restore(SettingsSnapshot) error
+}
+
+// settings is the implementation of Settings.
+type settings struct {
+	uc *unixgram
+}
+
+func (uc *unixgram) Settings() Settings {
+	return &settings{uc: uc}
+}
+
+func (s *settings) Get(key string) (string, error) {
+	resp, err := s.uc.cmd("GET " + key)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(string(resp), "\n"), nil
+}
+
+func (s *settings) GetInt(key string) (int, error) {
+	v, err := s.Get(key)
+	if err != nil {
+		return 0, err
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, &ParseError{Line: v, Err: err}
+	}
+	return i, nil
+}
+
+func (s *settings) GetBool(key string) (bool, error) {
+	i, err := s.GetInt(key)
+	return i != 0, err
+}
+
+func (s *settings) Set(key string, value interface{}) error {
+	var v string
+	switch value := value.(type) {
+	case string:
+		v = value
+	case int:
+		v = strconv.Itoa(value)
+	case bool:
+		v = "0"
+		if value {
+			v = "1"
+		}
+	default:
+		return errors.New("unsupported value type")
+	}
+
+	if err := validateSetting(key, v); err != nil {
+		return err
+	}
+
+	return s.uc.runCommand("SET " + key + " " + v)
+}
+
+// validateSetting checks the value of a known setting.
+func validateSetting(key, v string) error {
+	st, ok := knownSettings[key]
+	if !ok {
+		return nil
+	}
+
+	var err error
+	switch st.kind {
+	case settingInt:
+		var i int
+		if i, err = strconv.Atoi(v); err == nil && (i < st.min || i > st.max) {
+			err = fmt.Errorf("must be between %d and %d", st.min, st.max)
+		}
+	case settingBool:
+		if v != "0" && v != "1" {
+			err = errors.New("must be a boolean")
+		}
+	case settingString:
+		if st.validate != nil {
+			err = st.validate(v)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %v", key, v, err)
+	}
+	return nil
+}
+
+func (s *settings) Snapshot(keys ...string) (SettingsSnapshot, error) {
+	all := len(keys) == 0
+	if all {
+		for key := range knownSettings {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+	}
+
+	snap := make(SettingsSnapshot, len(keys))
+	for _, key := range keys {
+		v, err := s.Get(key)
+		if err != nil {
+			// Older versions don't know all the settings.
+			if all && errors.Is(err, ErrFail) {
+				continue
+			}
+			return nil, err
+		}
+		snap[key] = v
+	}
+	return snap, nil
+}
+
+func (s *settings) Restore(snap SettingsSnapshot) error {
+	keys := make([]string, 0, len(snap))
+	for key := range snap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var err error
+	for _, key := range keys {
+		// Restored values are set as they were read, without
+		// validation: unset strings read back as "".
+		if serr := s.uc.runCommand("SET " + key + " " + snap[key]); serr != nil && err == nil {
+			err = serr
+		}
+	}
+	return err
+}
@@ -0,0 +1,55 @@
+package wpasupplicant_test
+
+import "testing"
+
+func TestSettings(t *testing.T) {
+	_, conn := connect(t)
+	settings := conn.Settings()
+
+	snap, err := settings.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap["ap_scan"] != "1" {
+		t.Errorf("got snapshot %q", snap)
+	}
+
+	if err := settings.Set("ap_scan", 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := settings.Set("okc", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := settings.Set("sae_groups", "19 20 21"); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := settings.GetInt("ap_scan"); err != nil || v != 2 {
+		t.Errorf("ap_scan: got %d, %v", v, err)
+	}
+	if v, err := settings.GetBool("okc"); err != nil || !v {
+		t.Errorf("okc: got %t, %v", v, err)
+	}
+
+	for key, value := range map[string]interface{}{
+		"ap_scan":    3,
+		"okc":        "yes",
+		"pmf":        -1,
+		"sae_groups": "19,20",
+		"country":    "usa",
+		"autoscan":   "exponential 3 300",
+	} {
+		if err := settings.Set(key, value); err == nil {
+			t.Errorf("%s %v: expected an error", key, value)
+		}
+	}
+
+	if err := settings.Restore(snap); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := settings.Get("ap_scan"); err != nil || v != "1" {
+		t.Errorf("ap_scan not restored: got %q, %v", v, err)
+	}
+	if v, err := settings.Get("okc"); err != nil || v != "0" {
+		t.Errorf("okc not restored: got %q, %v", v, err)
+	}
+}
@@ -1,5 +1,10 @@
 package wpasupplicant
 
+import (
+	"encoding/json"
+	"strconv"
+)
+
 type StatusResult interface {
 	WPAState() string
 	KeyMgmt() string
@@ -8,6 +13,10 @@ type StatusResult interface {
 	Address() string
 	BSSID() string
 	Freq() string
+
+	// Channel is the channel of the current BSS, or the zero Channel if
+	// there is none.
+	Channel() Channel
 }
 
 type statusResult struct {
@@ -27,3 +36,65 @@ func (s *statusResult) SSID() string     { return s.ssid }
 func (s *statusResult) Address() string  { return s.address }
 func (s *statusResult) BSSID() string    { return s.bssid }
 func (s *statusResult) Freq() string     { return s.freq }
+
+func (s *statusResult) Channel() Channel {
+	freq, _ := strconv.Atoi(s.freq)
+	ch, _ := ChannelFromFrequency(freq)
+	return ch
+}
+
+// statusResultJSON is the JSON representation of a statusResult.  The
+// field names match the keys of the STATUS command output.
+type statusResultJSON struct {
+	WPAState string `json:"wpa_state"`
+	KeyMgmt  string `json:"key_mgmt,omitempty"`
+	IPAddr   string `json:"ip_address,omitempty"`
+	SSID     string `json:"ssid,omitempty"`
+	Address  string `json:"address,omitempty"`
+	BSSID    string `json:"bssid,omitempty"`
+	Freq     string `json:"freq,omitempty"`
+}
+
+// MarshalJSON encodes the status as an object with the fields wpa_state,
+// key_mgmt, ip_address, ssid, address, bssid and freq.  Empty fields are
+// omitted.
+func (s *statusResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(statusResultJSON{
+		WPAState: s.wpaState,
+		KeyMgmt:  s.keyMgmt,
+		IPAddr:   s.ipAddr,
+		SSID:     s.ssid,
+		Address:  s.address,
+		BSSID:    s.bssid,
+		Freq:     s.freq,
+	})
+}
+
+// UnmarshalJSON decodes the format written by MarshalJSON.
+func (s *statusResult) UnmarshalJSON(data []byte) error {
+	var v statusResultJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*s = statusResult{
+		wpaState: v.WPAState,
+		keyMgmt:  v.KeyMgmt,
+		ipAddr:   v.IPAddr,
+		ssid:     v.SSID,
+		address:  v.Address,
+		bssid:    v.BSSID,
+		freq:     v.Freq,
+	}
+	return nil
+}
+
+// UnmarshalStatusResult decodes a StatusResult previously encoded with
+// json.Marshal.
+func UnmarshalStatusResult(data []byte) (StatusResult, error) {
+	s := &statusResult{}
+	if err := s.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,172 @@
+package wpasupplicant
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceRecord is one line of a trace written by the Record option.  The
+// trace is a JSONL file with one record per datagram:
+//
+//	{"time":"2021-06-01T12:00:00.000000001Z","type":"command","data":"SCAN"}
+//	{"time":"2021-06-01T12:00:00.000200000Z","type":"reply","data":"OK\n"}
+//	{"time":"2021-06-01T12:00:00.000300000Z","type":"event","data":"<3>CTRL-EVENT-SCAN-STARTED "}
+//
+// Events are recorded with their priority prefix, exactly as received.
+// Secrets (passphrases, passwords, PINs, ...) are replaced by "[REDACTED]".
+type TraceRecord struct {
+	Time time.Time `json:"time"`
+	Type string    `json:"type"`
+	Data string    `json:"data"`
+}
+
+// Trace record types.
+const (
+	TraceCommand = "command"
+	TraceReply   = "reply"
+	TraceEvent   = "event"
+)
+
+// Redacted replaces secrets in a trace.
+const Redacted = "[REDACTED]"
+
+// secretVariables are the network and credential variables whose values
+// are redacted from traces.
+var secretVariables = map[string]bool{
+	"psk":                 true,
+	"password":            true,
+	"sae_password":        true,
+	"wep_key0":            true,
+	"wep_key1":            true,
+	"wep_key2":            true,
+	"wep_key3":            true,
+	"private_key_passwd":  true,
+	"private_key2_passwd": true,
+	"pin":                 true,
+	"pac_file":            true,
+	"milenage":            true,
+}
+
+// secretArguments matches secret key=value arguments of events and replies,
+// e.g. passphrase="..." in P2P-GROUP-STARTED.
+var secretArguments = regexp.MustCompile(`\b(passphrase|psk|password)=("[^"]*"|\S+)`)
+
+// tracer writes a trace of the traffic on a connection.
+type tracer struct {
+	lock    sync.Mutex
+	enc     *json.Encoder
+	lastCmd string
+}
+
+// Record writes a trace of every command, reply and event on the
+// connection to w, for later replay with wpatest.NewReplayServer.  See
+// TraceRecord for the format.  Write errors are ignored.
+func Record(w io.Writer) Option {
+	return func(conn *unixgram) error {
+		conn.tracer = &tracer{enc: json.NewEncoder(w)}
+		return nil
+	}
+}
+
+// command records a command sent to wpa_supplicant.
+func (t *tracer) command(cmd string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.lastCmd = cmd
+	t.write(TraceCommand, redactCommand(cmd))
+}
+
+// reply records a reply to the last command.
+func (t *tracer) reply(data []byte) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	reply := string(data)
+	_, cmd := splitIfname(t.lastCmd)
+	switch name := strings.SplitN(cmd, " ", 2)[0]; name {
+	case "WPS_PIN", "WPS_CHECK_PIN", "WPS_AP_PIN", "P2P_CONNECT":
+		// These reply with a PIN.
+		if reply != "" && reply[0] >= '0' && reply[0] <= '9' {
+			reply = Redacted + "\n"
+		}
+	}
+
+	t.write(TraceReply, secretArguments.ReplaceAllString(reply, "$1="+Redacted))
+}
+
+// event records an unsolicited message.
+func (t *tracer) event(data []byte) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.write(TraceEvent, secretArguments.ReplaceAllString(string(data), "$1="+Redacted))
+}
+
+func (t *tracer) write(typ, data string) {
+	_ = t.enc.Encode(TraceRecord{
+		Time: time.Now(),
+		Type: typ,
+		Data: data,
+	})
+}
+
+// redactCommand replaces secrets in a command.
+func redactCommand(cmd string) string {
+	if prefix, rest := splitIfname(cmd); prefix != "" {
+		return prefix + redactCommand(rest)
+	}
+
+	f := strings.SplitN(cmd, " ", 4)
+
+	switch f[0] {
+	case "SET_NETWORK", "SET_CRED":
+		// SET_NETWORK <id> <variable> <value>
+		if len(f) == 4 && secretVariables[f[2]] {
+			return strings.Join(f[:3], " ") + " " + Redacted
+		}
+	case "WPS_PIN", "WPS_REG", "WPS_AP_PIN", "P2P_CONNECT":
+		// WPS_PIN <bssid> <pin>, WPS_REG <bssid> <pin>,
+		// WPS_AP_PIN set <pin>, P2P_CONNECT <addr> <pin> ...
+		return redactPIN(cmd, 2)
+	case "WPS_CHECK_PIN":
+		return redactPIN(cmd, 1)
+	}
+
+	if strings.HasPrefix(cmd, "CTRL-RSP-") {
+		// CTRL-RSP-<field>-<id>:<value>
+		if i := strings.IndexByte(cmd, ':'); i != -1 {
+			return cmd[:i+1] + Redacted
+		}
+	}
+
+	return secretArguments.ReplaceAllString(cmd, "$1="+Redacted)
+}
+
+// splitIfname splits the "IFNAME=<iface> " prefix of commands for an
+// interface on a global control interface from the command itself.  The
+// prefix is empty if there is none.
+func splitIfname(cmd string) (prefix, rest string) {
+	if strings.HasPrefix(cmd, "IFNAME=") {
+		if i := strings.IndexByte(cmd, ' '); i != -1 {
+			return cmd[:i+1], cmd[i+1:]
+		}
+	}
+	return "", cmd
+}
+
+// redactPIN replaces the n'th space separated field of cmd if it is
+// numeric.
+func redactPIN(cmd string, n int) string {
+	f := strings.Split(cmd, " ")
+	if len(f) <= n || f[n] == "" || strings.Trim(f[n], "0123456789-") != "" {
+		return cmd
+	}
+
+	f[n] = Redacted
+	return strings.Join(f, " ")
+}
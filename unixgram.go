@@ -47,6 +47,7 @@ import (
 // daemon.  Messages may be either solicited or unsolicited.
 type message struct {
 	priority int
+	ifname   string
 	data     []byte
 	err      error
 }
@@ -62,8 +63,64 @@ type unixgram struct {
 	solicited, unsolicited chan message
 	wpaEvents              chan WPAEvent
 	lock                   sync.Mutex
+
+	// subscribers are the channels returned by Subscribe.  subClosed is
+	// set once Close ended every subscription.
+	subLock     sync.Mutex
+	subscribers map[chan WPAEvent]struct{}
+	subClosed   bool
+
+	// timeout is how long cmd waits for a reply.  Zero means forever.
+	timeout time.Duration
+
+	// tracer, if set, records all traffic.
+	tracer *tracer
+
+	// For a per-interface view of a global control interface, parent is
+	// the global connection and ifname the interface commands are
+	// addressed to.
+	parent *unixgram
+	ifname string
+
+	// For a global control interface, the per-interface views by name.
+	viewLock sync.Mutex
+	views    map[string]*unixgram
+
+	// ap is the access point started with StartAccessPoint, if any.
+	apLock sync.Mutex
+	ap     *apState
+
+	// prompter, if set, answers credential requests.  prompts are the
+	// requests being answered, by interface, field and network.
+	prompter      CredentialPrompter
+	promptTimeout time.Duration
+	promptLock    sync.Mutex
+	prompts       map[string]*pendingPrompt
+
+	// ignoreCmd is the name of the BSSID ignore list command, once
+	// known.
+	ignoreLock sync.Mutex
+	ignoreCmd  string
+
+	// caps are the capabilities, once Capabilities was called.
+	capsLock sync.Mutex
+	caps     *Capabilities
+
+	// manual is true while in manual mode (see SetManualMode), and
+	// manualOnDial if the ManualMode option enables it on connecting.
+	manualLock   sync.Mutex
+	manual       bool
+	manualOnDial bool
 }
 
+// ErrTimeout is returned when wpa_supplicant doesn't reply to a command
+// within the timeout set with CommandTimeout.
+var ErrTimeout = errors.New("timed out waiting for wpa_supplicant reply")
+
+// subscriberQueueLen is the number of events buffered for each subscriber
+// before further events are dropped.
+const subscriberQueueLen = 32
+
 var _ Conn = (*unixgram)(nil)
 
 // Connect returns a connection to wpa_supplicant for the specified
@@ -74,20 +131,39 @@ func Connect(ctx context.Context, iface string, options ...Option) (Conn, error)
 
 // ConnectPath connects to iface within ctrlPath and returns a connection.
 func ConnectPath(ctx context.Context, ctrlPath string, iface string, options ...Option) (Conn, error) {
+	local, err := createLocalPath(iface)
+	if err != nil {
+		return nil, err
+	}
+
+	uc, err := dial(ctx, local, path.Join(ctrlPath, iface), options...)
+	if err != nil {
+		return nil, err
+	}
+
+	if uc.manualOnDial {
+		if err := uc.SetManualMode(true); err != nil {
+			uc.Close()
+			return nil, err
+		}
+	}
+
+	return uc, nil
+}
+
+// dial connects the local socket to the remote one, applies the options and
+// attaches to receive events.
+func dial(ctx context.Context, local, remote string, options ...Option) (*unixgram, error) {
 	var err error
 	uc := &unixgram{
 		ctx:         ctx,
-		solicited:   make(chan message),
+		solicited:   make(chan message, 1),
 		unsolicited: make(chan message),
 		wpaEvents:   make(chan WPAEvent),
+		subscribers: make(map[chan WPAEvent]struct{}),
 	}
 
-	local, err := createLocalPath(iface)
-	if err != nil {
-		return nil, err
-	}
-
-	defaults := []Option{CustomUnixgram(local, path.Join(ctrlPath, iface))}
+	defaults := []Option{CustomUnixgram(local, remote)}
 	defaults = append(defaults, options...)
 
 	for _, fn := range defaults {
@@ -112,24 +188,37 @@ func ConnectPath(ctx context.Context, ctrlPath string, iface string, options ...
 // socket, and routes them to the appropriate channel based on whether they
 // are solicited (in response to a request) or unsolicited.
 func (uc *unixgram) readLoop() {
-	buf := make([]byte, 2048)
 	for {
 		select {
 		case <-uc.ctx.Done():
 			return
 		default:
-			b, oob := make([]byte, 2048), make([]byte, 2048)
-			n, oobn, _, _, err := uc.conn.ReadMsgUnix(b, oob)
+			// Each datagram is a complete message.  wpa_supplicant
+			// never sends more than 4096 bytes at once.
+			b := make([]byte, 4096)
+			n, err := uc.conn.Read(b)
 			if err != nil {
+				if errors.Is(err, net.ErrClosed) {
+					return
+				}
 				uc.solicited <- message{
 					err: err,
 				}
 				continue
 			}
-
-			// rebuild data buffer
-			b = append(buf, b[:n]...)
-			buf = oob[:oobn]
+			b = b[:n]
+			raw := b
+
+			// Events received on a global control interface are
+			// prefixed with the interface they concern, e.g.
+			// "IFNAME=wlan0 <3>CTRL-EVENT-CONNECTED ...".
+			var ifname string
+			if bytes.HasPrefix(b, []byte("IFNAME=")) {
+				if i := bytes.IndexByte(b, ' '); i != -1 {
+					ifname = string(b[len("IFNAME="):i])
+					b = b[i+1:]
+				}
+			}
 
 			// Unsolicited messages are preceded by a priority
 			// specification, e.g. "<1>message".  If there's no priority,
@@ -152,8 +241,17 @@ func (uc *unixgram) readLoop() {
 				p = 2
 			}
 
+			if uc.tracer != nil {
+				if c == uc.unsolicited {
+					uc.tracer.event(raw)
+				} else {
+					uc.tracer.reply(raw)
+				}
+			}
+
 			c <- message{
 				priority: p,
+				ifname:   ifname,
 				data:     b,
 			}
 		}
@@ -161,8 +259,7 @@ func (uc *unixgram) readLoop() {
 }
 
 // readUnsolicited handles messages sent to the unsolicited channel and parse them
-// into a WPAEvent. At the moment we only handle `CTRL-EVENT-*` events and only events
-// where the 'payload' is formatted with key=val.
+// into a WPAEvent, see parseEvent.
 func (uc *unixgram) readUnsolicited() {
 	for {
 		select {
@@ -170,61 +267,147 @@ func (uc *unixgram) readUnsolicited() {
 			return
 		default:
 			mgs := <-uc.unsolicited
-			data := bytes.NewBuffer(mgs.data).String()
 
-			parts := strings.Split(data, " ")
-			if len(parts) == 0 {
-				continue
-			}
+			e := parseEvent(bytes.NewBuffer(mgs.data).String())
+			e.Interface = mgs.ifname
+			uc.deliver(e)
 
-			var e WPAEvent
-			if strings.Index(parts[0], "CTRL-") != 0 {
-				e = WPAEvent{
-					Event: "MESSAGE",
-					Line:  data,
-				}
-			} else {
-				e = WPAEvent{
-					Event:     strings.TrimPrefix(parts[0], "CTRL-EVENT-"),
-					Arguments: make(map[string]string),
-					Line:      data,
+			var v *unixgram
+			if mgs.ifname != "" {
+				uc.viewLock.Lock()
+				v = uc.views[mgs.ifname]
+				uc.viewLock.Unlock()
+
+				if v != nil {
+					v.deliver(e)
 				}
+			}
 
-				for _, args := range parts[1:] {
-					if strings.Contains(args, "=") {
-						keyval := strings.Split(args, "=")
-						if len(keyval) != 2 {
-							continue
-						}
-						e.Arguments[keyval[0]] = keyval[1]
-					}
+			// A credential request is answered by the view of its
+			// interface, if there is one, so that closing the view
+			// abandons it.
+			if req, ok := ParseCredentialRequest(e); ok {
+				p := uc
+				if v != nil {
+					p = v
+				}
+				if p.prompter != nil {
+					go p.prompt(req)
 				}
 			}
+		}
+	}
+}
 
-			select {
-			case uc.wpaEvents <- e:
-			case <-time.After(time.Millisecond):
+// deliver sends an event to the subscribers and the event queue.
+func (uc *unixgram) deliver(e WPAEvent) {
+	uc.publish(e)
+
+	select {
+	case uc.wpaEvents <- e:
+	case <-time.After(time.Millisecond):
+	}
+}
+
+// await reads events until fn returns true or an error, the subscription
+// ends or ctx is done.
+func await(ctx context.Context, events <-chan WPAEvent, fn func(WPAEvent) (bool, error)) error {
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return errors.New("event subscription closed")
+			}
+			if done, err := fn(e); done || err != nil {
+				return err
 			}
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
 }
 
-// cmd executes a command and waits for a reply.
+// publish delivers an event to every subscriber without blocking.
+func (uc *unixgram) publish(e WPAEvent) {
+	uc.subLock.Lock()
+	defer uc.subLock.Unlock()
+
+	for c := range uc.subscribers {
+		select {
+		case c <- e:
+		default:
+		}
+	}
+}
+
+// cmd executes a command and waits for a reply.  Failure replies are
+// returned as a *CommandError.
 func (uc *unixgram) cmd(cmd string) ([]byte, error) {
+	var resp []byte
+	var err error
+	if uc.parent != nil {
+		resp, err = uc.parent.send("IFNAME=" + uc.ifname + " " + cmd)
+	} else {
+		resp, err = uc.send(cmd)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := replyError(cmd, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// send sends a command and waits for the reply, whatever it is.
+func (uc *unixgram) send(cmd string) ([]byte, error) {
+	if len(cmd) > maxCommandLen {
+		return nil, &CommandError{Command: redactCommand(cmd), Code: ErrTooLong}
+	}
+
 	uc.lock.Lock()
 	defer uc.lock.Unlock()
 
+	// Discard a reply which arrived after its command timed out, so it
+	// isn't mistaken for the reply to this one.
+	select {
+	case <-uc.solicited:
+	default:
+	}
+
+	if uc.tracer != nil {
+		uc.tracer.command(cmd)
+	}
+
 	_, err := uc.conn.Write([]byte(cmd))
+	if errors.Is(err, os.ErrPermission) {
+		return nil, &CommandError{Command: redactCommand(cmd), Code: ErrPermission}
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	msg := <-uc.solicited
-	return msg.data, msg.err
+	if uc.timeout == 0 {
+		msg := <-uc.solicited
+		return msg.data, msg.err
+	}
+
+	t := time.NewTimer(uc.timeout)
+	defer t.Stop()
+
+	select {
+	case msg := <-uc.solicited:
+		return msg.data, msg.err
+	case <-t.C:
+		return nil, ErrTimeout
+	}
 }
 
 // runCommand is a wrapper around the uc.cmd command which makes sure the
-// command returned a successful (OK) response.
+// command returned a successful (OK) response.  Failure replies are
+// returned as a *CommandError, and other unexpected replies as a
+// *ParseError.
 func (uc *unixgram) runCommand(cmd string) error {
 	resp, err := uc.cmd(cmd)
 	if err != nil {
@@ -242,6 +425,42 @@ func (uc *unixgram) EventQueue() chan WPAEvent {
 	return uc.wpaEvents
 }
 
+func (uc *unixgram) Subscribe() (<-chan WPAEvent, func()) {
+	c := make(chan WPAEvent, subscriberQueueLen)
+
+	uc.subLock.Lock()
+	if uc.subClosed {
+		close(c)
+	} else {
+		uc.subscribers[c] = struct{}{}
+	}
+	uc.subLock.Unlock()
+
+	return c, func() {
+		uc.subLock.Lock()
+		defer uc.subLock.Unlock()
+
+		// The channel is already closed if the connection was.
+		if _, ok := uc.subscribers[c]; ok {
+			delete(uc.subscribers, c)
+			close(c)
+		}
+	}
+}
+
+// closeSubscribers ends every subscription, so that their readers don't
+// wait for events which will never come.
+func (uc *unixgram) closeSubscribers() {
+	uc.subLock.Lock()
+	defer uc.subLock.Unlock()
+
+	for c := range uc.subscribers {
+		delete(uc.subscribers, c)
+		close(c)
+	}
+	uc.subClosed = true
+}
+
 func (uc *unixgram) Ping() error {
 	resp, err := uc.cmd("PING")
 	if err != nil {
@@ -288,11 +507,36 @@ func (uc *unixgram) RemoveAllNetworks() error {
 	return uc.runCommand("REMOVE_NETWORK all")
 }
 
+// unquotedVariables are the network variables whose string values are
+// keywords or addresses, which SetNetwork doesn't quote.
+var unquotedVariables = map[string]bool{
+	"key_mgmt":   true,
+	"proto":      true,
+	"pairwise":   true,
+	"group":      true,
+	"group_mgmt": true,
+	"auth_alg":   true,
+	"eap":        true,
+	"bssid":      true,
+
+	"bssid_ignore":    true,
+	"bssid_accept":    true,
+	"bssid_blacklist": true,
+	"bssid_whitelist": true,
+}
+
 func (uc *unixgram) SetNetwork(networkID int, variable string, value interface{}) error {
+	return uc.setVariable("SET_NETWORK", networkID, variable, value, unquotedVariables)
+}
+
+// setVariable sets a variable of a network or credential with cmd, which is
+// SET_NETWORK or SET_CRED.  unquoted are the variables whose string values
+// aren't quoted.
+func (uc *unixgram) setVariable(cmd string, id int, variable string, value interface{}, unquoted map[string]bool) error {
 	b := strings.Builder{}
-	b.WriteString("SET_NETWORK")
+	b.WriteString(cmd)
 	b.WriteString(" ")
-	b.WriteString(strconv.Itoa(networkID))
+	b.WriteString(strconv.Itoa(id))
 	b.WriteString(" ")
 	b.WriteString(variable)
 	b.WriteString(" ")
@@ -301,10 +545,10 @@ func (uc *unixgram) SetNetwork(networkID int, variable string, value interface{}
 	// Update: since we have to support AP mode, we need to support integer value (and hex value that just for non-ascii ssid)
 	switch v := value.(type) {
 	case string:
-		switch variable {
-		case "key_mgmt":
+		// A password may also be given as an NtPasswordHash.
+		if unquoted[variable] || variable == "password" && strings.HasPrefix(v, "hash:") {
 			b.WriteString(v)
-		default:
+		} else {
 			b.WriteString("\"")
 			b.WriteString(v)
 			b.WriteString("\"")
@@ -340,7 +584,7 @@ func (uc *unixgram) Scan() error {
 	return uc.runCommand("SCAN")
 }
 
-func (uc *unixgram) ScanResults() ([]ScanResult, []error) {
+func (uc *unixgram) ScanResults() (ScanResults, []error) {
 	resp, err := uc.cmd("SCAN_RESULTS")
 	if err != nil {
 		return nil, []error{err}
@@ -368,9 +612,56 @@ func (uc *unixgram) ListNetworks() ([]ConfiguredNetwork, error) {
 }
 
 func (uc *unixgram) Close() error {
+	// Failing to leave manual mode doesn't prevent closing.
+	_ = uc.restoreAutoconnect()
+
+	if uc.parent != nil {
+		uc.parent.viewLock.Lock()
+		delete(uc.parent.views, uc.ifname)
+		uc.parent.viewLock.Unlock()
+		if uc.prompter != nil {
+			uc.cancelPrompts()
+		}
+		uc.closeSubscribers()
+		return nil
+	}
+
+	// The views must leave manual mode while the socket is open.
+	uc.viewLock.Lock()
+	views := make([]*unixgram, 0, len(uc.views))
+	for _, v := range uc.views {
+		views = append(views, v)
+	}
+	uc.viewLock.Unlock()
+	for _, v := range views {
+		_ = v.restoreAutoconnect()
+		if v.prompter != nil {
+			v.cancelPrompts()
+		}
+	}
+
 	defer os.Remove(uc.local)
 
+	// Subscriptions end with the connection, including those of the
+	// per-interface views.
+	defer func() {
+		uc.closeSubscribers()
+
+		uc.viewLock.Lock()
+		defer uc.viewLock.Unlock()
+		for _, v := range uc.views {
+			v.closeSubscribers()
+		}
+	}()
+
+	if uc.prompter != nil {
+		uc.cancelPrompts()
+	}
+
+	// The socket is closed even if DETACH fails, e.g. because
+	// wpa_supplicant is already gone.
 	if err := uc.runCommand("DETACH"); err != nil {
+		uc.conn.Close()
 		return err
 	}
 
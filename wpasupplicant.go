@@ -77,14 +77,30 @@ const (
 	OSEN
 	IEEE8021X_SUITE_B
 	IEEE8021X_SUITE_B_192
+	FILS_SHA256
+	FILS_SHA384
+	FT_FILS_SHA256
+	FT_FILS_SHA384
+	OWE
+	DPP
+	FT_IEEE8021X_SHA384
+	PASN
 )
 
 type Algorithm int
 
+// WPAEvent is an unsolicited event received from wpa_supplicant.  It is
+// encoded to JSON as {"event": ..., "arguments": {...}, "line": ...,
+// "interface": ...}.
 type WPAEvent struct {
-	Event     string
-	Arguments map[string]string
-	Line      string
+	Event     string            `json:"event"`
+	Arguments map[string]string `json:"arguments,omitempty"`
+	Line      string            `json:"line"`
+
+	// Interface is the network interface the event concerns.  It is only
+	// set for events received through a global control interface or a
+	// Manager.
+	Interface string `json:"interface,omitempty"`
 }
 
 // stdSocketPath is where to find the the AF_UNIX sockets for each interface.  It
@@ -0,0 +1,131 @@
+package wpatest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// redacted is the marker wpasupplicant.Record puts in place of secrets.
+const redacted = "[REDACTED]"
+
+// traceRecord is one line of a trace written by wpasupplicant.Record.
+type traceRecord struct {
+	Type string `json:"type"`
+	Data string `json:"data"`
+}
+
+// replay serves replies and events from a recorded trace.
+type replay struct {
+	records    []traceRecord
+	pos        int
+	mismatches []string
+}
+
+// NewReplayServer creates a server which answers commands from a trace
+// written by the wpasupplicant.Record option.  Each command must match the
+// next command in the trace; it gets the recorded reply (or none, if the
+// command timed out when it was recorded), followed by the events which
+// were recorded before the next command.  Secrets redacted from the trace
+// match anything.
+//
+// Commands which don't match the trace get "FAIL\n" and are reported by
+// Mismatches.
+func NewReplayServer(iface string, trace io.Reader) (*Server, error) {
+	r := &replay{}
+
+	s := bufio.NewScanner(trace)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+	for s.Scan() {
+		if strings.TrimSpace(s.Text()) == "" {
+			continue
+		}
+
+		var rec traceRecord
+		if err := json.Unmarshal(s.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", len(r.records)+1, err)
+		}
+		r.records = append(r.records, rec)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return newServer("", iface, r)
+}
+
+// Mismatches returns the commands received by a replay server which didn't
+// match the trace.
+func (s *Server) Mismatches() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.replay == nil {
+		return nil
+	}
+	return append([]string(nil), s.replay.mismatches...)
+}
+
+// next answers a command from the trace.  The caller must hold s.mu.
+func (r *replay) next(s *Server, name, line string, addr *net.UnixAddr) (reply string, events []string, drop bool) {
+	switch name {
+	case "ATTACH":
+		s.attached[addr.Name] = addr
+	case "DETACH":
+		delete(s.attached, addr.Name)
+	}
+
+	// Events recorded before the command are sent before the reply.
+	for r.pos < len(r.records) && r.records[r.pos].Type != "command" {
+		if r.records[r.pos].Type == "event" {
+			s.emit(r.records[r.pos].Data)
+		}
+		r.pos++
+	}
+
+	if r.pos == len(r.records) || !matchRedacted(r.records[r.pos].Data, line) {
+		r.mismatches = append(r.mismatches, line)
+		return "FAIL\n", nil, false
+	}
+	r.pos++
+
+	drop = true
+	if r.pos < len(r.records) && r.records[r.pos].Type == "reply" {
+		reply, drop = r.records[r.pos].Data, false
+		r.pos++
+	}
+
+	for r.pos < len(r.records) && r.records[r.pos].Type == "event" {
+		events = append(events, r.records[r.pos].Data)
+		r.pos++
+	}
+
+	return reply, events, drop
+}
+
+// matchRedacted compares a recorded command with a received one, treating
+// redacted parts of the recorded command as wildcards.
+func matchRedacted(recorded, received string) bool {
+	parts := strings.Split(recorded, redacted)
+	if len(parts) == 1 {
+		return recorded == received
+	}
+
+	if !strings.HasPrefix(received, parts[0]) {
+		return false
+	}
+	received = received[len(parts[0]):]
+
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(received, part)
+		if i == -1 {
+			return false
+		}
+		received = received[i+len(part):]
+	}
+
+	return strings.HasSuffix(received, parts[len(parts)-1])
+}
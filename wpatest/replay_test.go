@@ -0,0 +1,73 @@
+package wpatest_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-laeo/wpasupplicant"
+	"github.com/go-laeo/wpasupplicant/wpatest"
+)
+
+// session runs the same sequence of commands against a connection.
+func session(t *testing.T, conn wpasupplicant.Conn) []wpasupplicant.ScanResult {
+	t.Helper()
+
+	events, unsubscribe := conn.Subscribe()
+	defer unsubscribe()
+
+	id, err := conn.AddNetwork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.SetNetwork(id, "psk", "correct horse battery staple"); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Scan(); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, events, "SCAN-RESULTS")
+
+	results, errs := conn.ScanResults()
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	return results
+}
+
+func TestRecordReplay(t *testing.T) {
+	trace := &bytes.Buffer{}
+	srv, conn := connect(t, wpasupplicant.Record(trace))
+	srv.SetBSSs(wpatest.BSS{BSSID: "02:00:00:00:01:00", SSID: "home", Frequency: 2412, Signal: -40, Flags: "[ESS]"})
+
+	recorded := session(t, conn)
+	conn.Close()
+
+	if strings.Contains(trace.String(), "correct horse") {
+		t.Fatal("trace contains the psk")
+	}
+
+	replay, err := wpatest.NewReplayServer("wlan0", bytes.NewReader(trace.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replay.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conn, err = wpasupplicant.ConnectPath(ctx, replay.Dir(), replay.Iface(), wpasupplicant.CommandTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	replayed := session(t, conn)
+	if len(replayed) != len(recorded) || replayed[0].SSID() != recorded[0].SSID() {
+		t.Errorf("replayed %+v, recorded %+v", replayed, recorded)
+	}
+	if m := replay.Mismatches(); len(m) > 0 {
+		t.Errorf("mismatched commands: %q", m)
+	}
+}
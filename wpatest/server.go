@@ -0,0 +1,923 @@
+// Package wpatest provides a fake wpa_supplicant control interface for
+// testing code which uses package wpasupplicant without a real daemon.
+//
+// The Server listens on a unixgram socket in a temporary directory, so a
+// connection can be made with:
+//
+//	srv, err := wpatest.NewServer("wlan0")
+//	...
+//	defer srv.Close()
+//	conn, err := wpasupplicant.ConnectPath(ctx, srv.Dir(), srv.Iface())
+//
+// It implements enough of the control interface to exercise network
+// management, status, scanning and access point mode against a virtual radio
+// environment, and allows events and failures to be injected.
+package wpatest
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BSS is an access point in the virtual radio environment.
+type BSS struct {
+	BSSID     string
+	SSID      string
+	Frequency int
+	Signal    int
+
+	// Flags is the flags column of SCAN_RESULTS, e.g.
+	// "[WPA2-PSK-CCMP][ESS]".
+	Flags string
+
+	// IEs are the hex encoded information elements reported by the BSS
+	// command, e.g. "3d16..." for an HT Operation element.
+	IEs string
+}
+
+// Fault is a failure injected into the reply to a command.
+type Fault struct {
+	// Reply replaces the normal reply, e.g. "FAIL\n" or "FAIL-BUSY\n".
+	Reply string
+
+	// Drop suppresses the reply entirely, so the client times out.
+	Drop bool
+
+	// Truncate, if positive, cuts the reply down to this many bytes.
+	Truncate int
+}
+
+// HandlerFunc handles a command.  It is passed everything after the command
+// name, and returns the reply and any events to send to attached clients
+// after the reply.
+type HandlerFunc func(args string) (reply string, events []string)
+
+// network is a configured network.
+type network struct {
+	vars     map[string]string
+	disabled bool
+}
+
+// Server is a fake wpa_supplicant control interface.
+type Server struct {
+	dir, iface string
+	ownDir     bool
+	conn       *net.UnixConn
+	done       chan struct{}
+
+	mu          sync.Mutex
+	attached    map[string]*net.UnixAddr
+	networks    map[int]*network
+	nextID      int
+	creds       map[int]map[string]string
+	nextCred    int
+	settings    map[string]string
+	caps        map[string]string
+	current     int
+	assoc       string
+	ignored     []string
+	autoconnect bool
+	state       string
+	bss         []BSS
+	scanning    bool
+	scanDelay   time.Duration
+	address     string
+	faults      map[string][]Fault
+	handlers    map[string]HandlerFunc
+	commands    []string
+	replay      *replay
+}
+
+// NewServer creates a fake control interface for iface, listening in a new
+// temporary directory.
+func NewServer(iface string) (*Server, error) {
+	return newServer("", iface, nil)
+}
+
+// NewServerDir creates a fake control interface for iface in an existing
+// directory, e.g. to simulate an interface appearing next to others.  Close
+// removes only the socket.
+func NewServerDir(dir, iface string) (*Server, error) {
+	return newServer(dir, iface, nil)
+}
+
+// newServer creates a server in dir, or in a new temporary directory if dir
+// is empty.
+func newServer(dir, iface string, r *replay) (*Server, error) {
+	ownDir := dir == ""
+	if ownDir {
+		var err error
+		if dir, err = os.MkdirTemp("", "wpatest"); err != nil {
+			return nil, err
+		}
+	}
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: filepath.Join(dir, iface), Net: "unixgram"})
+	if err != nil {
+		if ownDir {
+			os.RemoveAll(dir)
+		}
+		return nil, err
+	}
+
+	s := &Server{
+		dir:         dir,
+		ownDir:      ownDir,
+		iface:       iface,
+		conn:        conn,
+		done:        make(chan struct{}),
+		attached:    make(map[string]*net.UnixAddr),
+		networks:    make(map[int]*network),
+		creds:       make(map[int]map[string]string),
+		settings:    defaultSettings(),
+		caps:        defaultCapabilities(),
+		current:     -1,
+		autoconnect: true,
+		state:       "DISCONNECTED",
+		scanDelay:   10 * time.Millisecond,
+		address:     "02:00:00:00:00:01",
+		faults:      make(map[string][]Fault),
+		handlers:    make(map[string]HandlerFunc),
+		replay:      r,
+	}
+
+	go s.serve()
+
+	return s, nil
+}
+
+// Dir is the control directory, to be passed to wpasupplicant.ConnectPath.
+func (s *Server) Dir() string { return s.dir }
+
+// Iface is the interface name the server was created for.
+func (s *Server) Iface() string { return s.iface }
+
+// Path is the path of the control socket.
+func (s *Server) Path() string { return filepath.Join(s.dir, s.iface) }
+
+// Close stops the server and removes its socket, and its directory if
+// NewServer created it.
+func (s *Server) Close() error {
+	err := s.conn.Close()
+	<-s.done
+	if s.ownDir {
+		os.RemoveAll(s.dir)
+	} else {
+		os.Remove(s.Path())
+	}
+	return err
+}
+
+// SetBSSs replaces the virtual radio environment.
+func (s *Server) SetBSSs(bss ...BSS) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bss = append([]BSS(nil), bss...)
+}
+
+// AddBSS adds an access point to the virtual radio environment, replacing
+// any existing one with the same BSSID.
+func (s *Server) AddBSS(b BSS) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.bss {
+		if s.bss[i].BSSID == b.BSSID {
+			s.bss[i] = b
+			return
+		}
+	}
+	s.bss = append(s.bss, b)
+}
+
+// RemoveBSS removes an access point from the virtual radio environment.
+func (s *Server) RemoveBSS(bssid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.bss {
+		if s.bss[i].BSSID == bssid {
+			s.bss = append(s.bss[:i], s.bss[i+1:]...)
+			return
+		}
+	}
+}
+
+// SetScanDelay sets how long a SCAN takes before results are reported.
+func (s *Server) SetScanDelay(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scanDelay = d
+}
+
+// Inject queues a fault for the next occurrence of cmd (the command name,
+// e.g. "SCAN").  Faults for the same command are used in order.
+func (s *Server) Inject(cmd string, f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[cmd] = append(s.faults[cmd], f)
+}
+
+// Handle installs a handler for cmd, replacing the built-in one if any.
+// Handlers are called with the server unlocked, so they may call other
+// Server methods.
+func (s *Server) Handle(cmd string, fn HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[cmd] = fn
+}
+
+// SetCapability sets the reply to GET_CAPABILITY name, e.g. "key_mgmt".
+// An empty value makes the query fail, as for unsupported fields.
+func (s *Server) SetCapability(name, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if value == "" {
+		delete(s.caps, name)
+		return
+	}
+	s.caps[name] = value
+}
+
+// Autoconnect returns false if automatic connection was disabled with
+// STA_AUTOCONNECT 0.
+func (s *Server) Autoconnect() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.autoconnect
+}
+
+// Emit sends an event, e.g. "CTRL-EVENT-SCAN-STARTED ", to every attached
+// client.
+func (s *Server) Emit(event string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.emit(info(event))
+}
+
+// EmitInterface sends an event concerning iface the way a global control
+// interface does, i.e. prefixed with "IFNAME=<iface> ".
+func (s *Server) EmitInterface(iface, event string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.emit("IFNAME=" + iface + " " + info(event))
+}
+
+// info prefixes an event with the MSG_INFO priority.
+func info(event string) string {
+	return "<3>" + event
+}
+
+// emit sends a datagram to every attached client.  The caller must hold
+// s.mu.
+func (s *Server) emit(datagram string) {
+	for _, addr := range s.attached {
+		_, _ = s.conn.WriteToUnix([]byte(datagram), addr)
+	}
+}
+
+// Commands returns every command received so far, in order.
+func (s *Server) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+// Network returns the variables set on a network, as they were sent (i.e.
+// strings are still quoted), or nil if there is no such network.
+func (s *Server) Network(id int) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.networks[id]
+	if !ok {
+		return nil
+	}
+
+	vars := make(map[string]string, len(n.vars))
+	for k, v := range n.vars {
+		vars[k] = v
+	}
+	return vars
+}
+
+// Cred returns the variables set on a credential, as they were sent, or nil
+// if there is no such credential.
+func (s *Server) Cred(id int) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.creds[id]
+	if !ok {
+		return nil
+	}
+
+	vars := make(map[string]string, len(c))
+	for k, v := range c {
+		vars[k] = v
+	}
+	return vars
+}
+
+// serve reads and answers commands until the socket is closed.
+func (s *Server) serve() {
+	defer close(s.done)
+
+	buf := make([]byte, 4096)
+	for {
+		n, addr, err := s.conn.ReadFromUnix(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		if addr == nil {
+			continue
+		}
+
+		reply, events, drop := s.dispatch(string(buf[:n]), addr)
+		if !drop {
+			_, _ = s.conn.WriteToUnix([]byte(reply), addr)
+		}
+
+		s.mu.Lock()
+		for _, e := range events {
+			s.emit(e)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// dispatch runs a command and returns its reply and the datagrams to send
+// to attached clients afterwards, applying any injected fault.
+func (s *Server) dispatch(line string, addr *net.UnixAddr) (reply string, events []string, drop bool) {
+	name, args := line, ""
+	if i := strings.IndexByte(line, ' '); i != -1 {
+		name, args = line[:i], line[i+1:]
+	}
+
+	s.mu.Lock()
+	s.commands = append(s.commands, line)
+
+	if s.replay != nil {
+		defer s.mu.Unlock()
+		return s.replay.next(s, name, line, addr)
+	}
+
+	var fault *Fault
+	if q := s.faults[name]; len(q) > 0 {
+		fault = &q[0]
+		s.faults[name] = q[1:]
+	}
+
+	if fn, ok := s.handlers[name]; ok {
+		s.mu.Unlock()
+		reply, events = fn(args)
+	} else {
+		reply, events = s.builtin(name, args, addr)
+		s.mu.Unlock()
+	}
+
+	for i := range events {
+		events[i] = info(events[i])
+	}
+
+	if fault != nil {
+		if fault.Drop {
+			return "", nil, true
+		}
+		if fault.Reply != "" {
+			reply, events = fault.Reply, nil
+		}
+		if fault.Truncate > 0 && fault.Truncate < len(reply) {
+			reply = reply[:fault.Truncate]
+		}
+	}
+
+	return reply, events, false
+}
+
+// builtin implements the commands the server knows about.  The caller must
+// hold s.mu.
+func (s *Server) builtin(name, args string, addr *net.UnixAddr) (string, []string) {
+	// Credential responses are accepted without checking them.
+	if strings.HasPrefix(name, "CTRL-RSP-") {
+		return "OK\n", nil
+	}
+
+	switch name {
+	case "PING":
+		return "PONG\n", nil
+	case "ATTACH":
+		s.attached[addr.Name] = addr
+		return "OK\n", nil
+	case "DETACH":
+		if _, ok := s.attached[addr.Name]; !ok {
+			return "FAIL\n", nil
+		}
+		delete(s.attached, addr.Name)
+		return "OK\n", nil
+	case "ADD_NETWORK":
+		id := s.nextID
+		s.nextID++
+		s.networks[id] = &network{vars: make(map[string]string), disabled: true}
+		return strconv.Itoa(id) + "\n", nil
+	case "SET_NETWORK":
+		f := strings.SplitN(args, " ", 3)
+		if len(f) != 3 {
+			return "FAIL\n", nil
+		}
+		n := s.network(f[0])
+		if n == nil {
+			return "FAIL\n", nil
+		}
+		n.vars[f[1]] = f[2]
+		return "OK\n", nil
+	case "ENABLE_NETWORK", "DISABLE_NETWORK":
+		disabled := name == "DISABLE_NETWORK"
+		if args == "all" {
+			for _, n := range s.networks {
+				n.disabled = disabled
+			}
+			return "OK\n", nil
+		}
+		n := s.network(args)
+		if n == nil {
+			return "FAIL\n", nil
+		}
+		n.disabled = disabled
+		return "OK\n", nil
+	case "SELECT_NETWORK":
+		id, err := strconv.Atoi(args)
+		if err != nil || s.networks[id] == nil {
+			return "FAIL\n", nil
+		}
+		for i, n := range s.networks {
+			n.disabled = i != id
+		}
+		return "OK\n", s.connect(id)
+	case "REMOVE_NETWORK":
+		var events []string
+		if args == "all" {
+			events = s.disconnect()
+			s.networks = make(map[int]*network)
+			return "OK\n", events
+		}
+		id, err := strconv.Atoi(args)
+		if err != nil || s.networks[id] == nil {
+			return "FAIL\n", nil
+		}
+		if id == s.current {
+			events = s.disconnect()
+		}
+		delete(s.networks, id)
+		return "OK\n", events
+	case "LIST_NETWORKS":
+		return s.listNetworks(), nil
+	case "ADD_CRED":
+		id := s.nextCred
+		s.nextCred++
+		s.creds[id] = make(map[string]string)
+		return strconv.Itoa(id) + "\n", nil
+	case "SET_CRED":
+		f := strings.SplitN(args, " ", 3)
+		if len(f) != 3 {
+			return "FAIL\n", nil
+		}
+		id, err := strconv.Atoi(f[0])
+		if err != nil || s.creds[id] == nil {
+			return "FAIL\n", nil
+		}
+		s.creds[id][f[1]] = f[2]
+		return "OK\n", nil
+	case "REMOVE_CRED":
+		id, err := strconv.Atoi(args)
+		if err != nil || s.creds[id] == nil {
+			return "FAIL\n", nil
+		}
+		delete(s.creds, id)
+		return "OK\n", nil
+	case "LIST_CREDS":
+		return s.listCreds(), nil
+	case "GET":
+		v, ok := s.settings[args]
+		if !ok {
+			return "FAIL\n", nil
+		}
+		return v, nil
+	case "SET":
+		f := strings.SplitN(args, " ", 2)
+		if len(f) != 2 {
+			return "FAIL\n", nil
+		}
+		s.settings[f[0]] = f[1]
+		if f[0] == "country" {
+			return "OK\n", []string{"CTRL-EVENT-REGDOM-CHANGE init=USER type=COUNTRY alpha2=" + f[1]}
+		}
+		return "OK\n", nil
+	case "STATUS":
+		return s.status(), nil
+	case "SCAN":
+		if s.scanning {
+			return "FAIL-BUSY\n", nil
+		}
+		s.scanning = true
+		time.AfterFunc(s.scanDelay, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.scanning = false
+			s.emit(info("CTRL-EVENT-SCAN-RESULTS "))
+		})
+		return "OK\n", []string{"CTRL-EVENT-SCAN-STARTED "}
+	case "SCAN_RESULTS":
+		return s.scanResults(), nil
+	case "BSS":
+		return s.bssInfo(args), nil
+	case "BSSID_IGNORE", "BLACKLIST":
+		return s.bssidIgnore(args), nil
+	case "ROAM", "FT_DS":
+		return s.roam(args)
+	case "NEIGHBOR_REP_REQUEST":
+		return s.neighborReport()
+	case "WNM_BSS_QUERY", "WNM_SLEEP":
+		if s.currentBSS() == nil {
+			return "FAIL\n", nil
+		}
+		return "OK\n", nil
+	case "SIGNAL_POLL":
+		return s.signalPoll(), nil
+	case "SAVE_CONFIG", "RECONFIGURE", "REASSOCIATE", "RECONNECT",
+		"REAUTHENTICATE", "LOGON", "LOGOFF", "SUSPEND", "RESUME":
+		return "OK\n", nil
+	case "DISCONNECT":
+		return "OK\n", s.disconnect()
+	case "PREAUTH":
+		if _, err := net.ParseMAC(args); err != nil {
+			return "FAIL\n", nil
+		}
+		return "OK\n", nil
+	case "STA_AUTOCONNECT":
+		if args != "0" && args != "1" {
+			return "FAIL\n", nil
+		}
+		s.autoconnect = args == "1"
+		return "OK\n", nil
+	case "ABORT_SCAN":
+		if !s.scanning {
+			return "FAIL\n", nil
+		}
+		return "OK\n", nil
+	case "FLUSH":
+		events := s.disconnect()
+		s.networks = make(map[int]*network)
+		s.creds = make(map[int]map[string]string)
+		s.ignored = nil
+		return "OK\n", events
+	case "TERMINATE":
+		return "OK\n", []string{"CTRL-EVENT-TERMINATING "}
+	case "DRIVER":
+		return "FAIL\n", nil
+	case "GET_CAPABILITY":
+		v, ok := s.caps[strings.TrimSuffix(args, " strict")]
+		if !ok {
+			return "FAIL\n", nil
+		}
+		return v, nil
+	}
+
+	return "UNKNOWN COMMAND\n", nil
+}
+
+// defaultCapabilities are the GET_CAPABILITY replies of a new server, for
+// a dual band device supporting WPA3.  Unlisted fields fail.
+func defaultCapabilities() map[string]string {
+	return map[string]string{
+		"eap":      "MD5 TLS MSCHAPV2 PEAP TTLS GTC OTP SIM AKA AKA' PWD",
+		"pairwise": "CCMP-256 GCMP-256 CCMP GCMP TKIP NONE",
+		"group":    "CCMP-256 GCMP-256 CCMP GCMP TKIP",
+		"key_mgmt": "NONE IEEE8021X WPA-EAP WPA-PSK FT-PSK FT-EAP SAE FT-SAE OWE",
+		"proto":    "RSN WPA",
+		"auth_alg": "OPEN SHARED",
+		"modes":    "IBSS AP",
+		"channels": "Mode[B] Channels: 1 6 11\nMode[G] Channels: 1 6 11\nMode[A] Channels: 36 52\n",
+		"freq": "Mode[B] Channels:\n 1 = 2412 MHz\n 6 = 2437 MHz\n 11 = 2462 MHz\n" +
+			"Mode[G] Channels:\n 1 = 2412 MHz\n 6 = 2437 MHz\n 11 = 2462 MHz\n" +
+			"Mode[A] Channels:\n 36 = 5180 MHz\n 52 = 5260 MHz (NO_IR) (DFS)\n",
+		"tdls": "EXTERNAL",
+		"pmf":  "PMF",
+		"sae":  "H2E",
+		"dpp":  "DPP=2",
+	}
+}
+
+// defaultSettings are the global settings of a new server, which GET
+// reports.  Other settings can be SET, after which they are reported too.
+func defaultSettings() map[string]string {
+	return map[string]string{
+		"country":                   "00",
+		"ap_scan":                   "1",
+		"fast_reauth":               "1",
+		"bss_expiration_age":        "180",
+		"bss_expiration_scan_count": "2",
+		"pmf":                       "0",
+		"mac_addr":                  "0",
+		"rand_addr_lifetime":        "60",
+		"preassoc_mac_addr":         "0",
+		"okc":                       "0",
+		"update_config":             "0",
+		"filter_ssids":              "0",
+	}
+}
+
+// network returns the network with the given (string) id, or nil.
+func (s *Server) network(id string) *network {
+	i, err := strconv.Atoi(id)
+	if err != nil {
+		return nil
+	}
+	return s.networks[i]
+}
+
+// connect associates with the strongest BSS matching the SSID of the given
+// network, returning the resulting events.
+func (s *Server) connect(id int) []string {
+	events := s.disconnect()
+
+	// AP mode networks start an access point.
+	if s.networks[id].vars["mode"] == "2" {
+		s.current = id
+		s.state = "COMPLETED"
+		return append(events, "AP-ENABLED ")
+	}
+
+	ssid := unquote(s.networks[id].vars["ssid"])
+	var best *BSS
+	for i := range s.bss {
+		if s.bss[i].SSID == ssid && !s.isIgnored(s.bss[i].BSSID) && (best == nil || s.bss[i].Signal > best.Signal) {
+			best = &s.bss[i]
+		}
+	}
+
+	if best == nil {
+		s.state = "SCANNING"
+		return append(events, "CTRL-EVENT-NETWORK-NOT-FOUND")
+	}
+
+	s.current = id
+	s.assoc = best.BSSID
+	s.state = "COMPLETED"
+	return append(events, fmt.Sprintf("CTRL-EVENT-CONNECTED - Connection to %s completed [id=%d id_str=]", best.BSSID, id))
+}
+
+// disconnect drops the current association, if any, returning the
+// resulting events.
+func (s *Server) disconnect() []string {
+	if s.current == -1 {
+		return nil
+	}
+
+	bss := s.currentBSS()
+	ap := s.isAP()
+	s.current = -1
+	s.assoc = ""
+	s.state = "DISCONNECTED"
+	if ap {
+		return []string{"AP-DISABLED "}
+	}
+	if bss == nil {
+		return nil
+	}
+	return []string{fmt.Sprintf("CTRL-EVENT-DISCONNECTED bssid=%s reason=3 locally_generated=1", bss.BSSID)}
+}
+
+// isAP returns true if the current network is an access point.
+func (s *Server) isAP() bool {
+	n, ok := s.networks[s.current]
+	return ok && n.vars["mode"] == "2"
+}
+
+// currentBSS returns the BSS we are associated with, or nil if it went
+// away.
+func (s *Server) currentBSS() *BSS {
+	n, ok := s.networks[s.current]
+	if !ok || s.isAP() {
+		return nil
+	}
+
+	return s.findBSS(unquote(n.vars["ssid"]), s.assoc)
+}
+
+// findBSS returns the BSS with the given SSID and BSSID, or nil.
+func (s *Server) findBSS(ssid, bssid string) *BSS {
+	for i := range s.bss {
+		if s.bss[i].SSID == ssid && strings.EqualFold(s.bss[i].BSSID, bssid) {
+			return &s.bss[i]
+		}
+	}
+	return nil
+}
+
+// roam reassociates with another BSS of the current network.
+func (s *Server) roam(bssid string) (string, []string) {
+	n, ok := s.networks[s.current]
+	if !ok || s.isAP() {
+		return "FAIL\n", nil
+	}
+	bss := s.findBSS(unquote(n.vars["ssid"]), bssid)
+	if bss == nil {
+		return "FAIL\n", nil
+	}
+
+	s.assoc = bss.BSSID
+	return "OK\n", []string{fmt.Sprintf("CTRL-EVENT-CONNECTED - Connection to %s completed [id=%d id_str=]", bss.BSSID, s.current)}
+}
+
+// bssidIgnore lists, clears or adds to the BSSID ignore list.
+func (s *Server) bssidIgnore(args string) string {
+	switch args {
+	case "":
+		if len(s.ignored) == 0 {
+			return ""
+		}
+		return strings.Join(s.ignored, "\n") + "\n"
+	case "clear":
+		s.ignored = nil
+		return "OK\n"
+	}
+
+	if _, err := net.ParseMAC(args); err != nil {
+		return "FAIL\n"
+	}
+	if !s.isIgnored(args) {
+		s.ignored = append(s.ignored, strings.ToLower(args))
+	}
+	return "OK\n"
+}
+
+// isIgnored returns true if a BSSID is in the ignore list.
+func (s *Server) isIgnored(bssid string) bool {
+	for _, b := range s.ignored {
+		if strings.EqualFold(b, bssid) {
+			return true
+		}
+	}
+	return false
+}
+
+// neighborReport reports the other BSSs of the current network as
+// neighbors.
+func (s *Server) neighborReport() (string, []string) {
+	cur := s.currentBSS()
+	if cur == nil {
+		return "FAIL\n", nil
+	}
+
+	var events []string
+	for _, bss := range s.bss {
+		if bss.SSID != cur.SSID || bss.BSSID == cur.BSSID {
+			continue
+		}
+		opClass, ch := opClass(bss.Frequency)
+		events = append(events, fmt.Sprintf("RRM-NEIGHBOR-REP-RECEIVED bssid=%s info=0x8f op_class=%d chan=%d phy_type=9", bss.BSSID, opClass, ch))
+	}
+	return "OK\n", events
+}
+
+// opClass returns the global operating class and channel of a 20 MHz
+// channel.
+func opClass(freq int) (int, int) {
+	switch {
+	case freq < 2484:
+		return 81, (freq - 2407) / 5
+	case freq == 2484:
+		return 82, 14
+	case freq < 5950:
+		ch := (freq - 5000) / 5
+		switch {
+		case ch <= 48:
+			return 115, ch
+		case ch <= 64:
+			return 118, ch
+		case ch <= 144:
+			return 121, ch
+		}
+		return 125, ch
+	case freq < 7125:
+		return 131, (freq - 5950) / 5
+	}
+	return 180, (freq - 56160) / 2160
+}
+
+// signalPoll returns the reply to SIGNAL_POLL.
+func (s *Server) signalPoll() string {
+	bss := s.currentBSS()
+	if bss == nil {
+		return "FAIL\n"
+	}
+	return fmt.Sprintf("RSSI=%d\nLINKSPEED=144\nNOISE=9999\nFREQUENCY=%d\nWIDTH=20 MHz\nAVG_RSSI=%d\n", bss.Signal, bss.Frequency, bss.Signal)
+}
+
+func (s *Server) listNetworks() string {
+	ids := make([]int, 0, len(s.networks))
+	for id := range s.networks {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	b := &strings.Builder{}
+	b.WriteString("network id / ssid / bssid / flags\n")
+	for _, id := range ids {
+		n := s.networks[id]
+
+		var flags string
+		switch {
+		case id == s.current:
+			flags = "[CURRENT]"
+		case n.disabled:
+			flags = "[DISABLED]"
+		}
+
+		bssid := "any"
+		if v, ok := n.vars["bssid"]; ok {
+			bssid = v
+		}
+
+		fmt.Fprintf(b, "%d\t%s\t%s\t%s\n", id, unquote(n.vars["ssid"]), bssid, flags)
+	}
+	return b.String()
+}
+
+func (s *Server) listCreds() string {
+	ids := make([]int, 0, len(s.creds))
+	for id := range s.creds {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	b := &strings.Builder{}
+	b.WriteString("cred id / realm / username / domain / imsi\n")
+	for _, id := range ids {
+		c := s.creds[id]
+		fmt.Fprintf(b, "%d\t%s\t%s\t%s\t%s\n", id, unquote(c["realm"]), unquote(c["username"]), unquote(c["domain"]), unquote(c["imsi"]))
+	}
+	return b.String()
+}
+
+func (s *Server) status() string {
+	b := &strings.Builder{}
+	if s.isAP() {
+		n := s.networks[s.current]
+		fmt.Fprintf(b, "bssid=%s\nfreq=%s\nssid=%s\nid=%d\nmode=AP\n", s.address, n.vars["frequency"], unquote(n.vars["ssid"]), s.current)
+	}
+	if bss := s.currentBSS(); bss != nil {
+		fmt.Fprintf(b, "bssid=%s\nfreq=%d\nssid=%s\nid=%d\nmode=station\n", bss.BSSID, bss.Frequency, bss.SSID, s.current)
+		if km, ok := s.networks[s.current].vars["key_mgmt"]; ok {
+			fmt.Fprintf(b, "key_mgmt=%s\n", km)
+		}
+	}
+	fmt.Fprintf(b, "wpa_state=%s\naddress=%s\n", s.state, s.address)
+	return b.String()
+}
+
+func (s *Server) scanResults() string {
+	b := &strings.Builder{}
+	b.WriteString("bssid / frequency / signal level / flags / ssid\n")
+	for _, bss := range s.bss {
+		fmt.Fprintf(b, "%s\t%d\t%d\t%s\t%s\n", bss.BSSID, bss.Frequency, bss.Signal, bss.Flags, bss.SSID)
+	}
+	return b.String()
+}
+
+// bssInfo returns the reply to the BSS command, which is empty for unknown
+// BSSs.
+func (s *Server) bssInfo(bssid string) string {
+	for i, bss := range s.bss {
+		if strings.EqualFold(bss.BSSID, bssid) {
+			return fmt.Sprintf("id=%d\nbssid=%s\nfreq=%d\nnoise=-95\nlevel=%d\nage=0\nie=%s\nflags=%s\nssid=%s\n",
+				i, bss.BSSID, bss.Frequency, bss.Signal, bss.IEs, bss.Flags, bss.SSID)
+		}
+	}
+	return ""
+}
+
+// unquote decodes a network variable value: either a quoted string or a
+// hex encoded one.
+func unquote(v string) string {
+	if len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"' {
+		return v[1 : len(v)-1]
+	}
+	if b, err := hex.DecodeString(v); err == nil {
+		return string(b)
+	}
+	return v
+}
@@ -0,0 +1,156 @@
+package wpatest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-laeo/wpasupplicant"
+	"github.com/go-laeo/wpasupplicant/wpatest"
+)
+
+// connect starts a server and connects to it, closing both when the test
+// ends.
+func connect(t *testing.T, options ...wpasupplicant.Option) (*wpatest.Server, wpasupplicant.Conn) {
+	t.Helper()
+
+	srv, err := wpatest.NewServer("wlan0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	options = append([]wpasupplicant.Option{wpasupplicant.CommandTimeout(time.Second)}, options...)
+	conn, err := wpasupplicant.ConnectPath(ctx, srv.Dir(), srv.Iface(), options...)
+	if err != nil {
+		cancel()
+		srv.Close()
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		conn.Close()
+		cancel()
+		srv.Close()
+	})
+
+	return srv, conn
+}
+
+// waitEvent waits for an event with the given name.
+func waitEvent(t *testing.T, events <-chan wpasupplicant.WPAEvent, name string) wpasupplicant.WPAEvent {
+	t.Helper()
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case e := <-events:
+			if e.Event == name {
+				return e
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s", name)
+		}
+	}
+}
+
+func TestConnectAndSelect(t *testing.T) {
+	srv, conn := connect(t)
+	srv.SetBSSs(wpatest.BSS{BSSID: "02:00:00:00:01:00", SSID: "home", Frequency: 2412, Signal: -40, Flags: "[WPA2-PSK-CCMP][ESS]"})
+
+	if err := conn.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := conn.AddNetwork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.SetNetwork(id, "ssid", "home"); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.SetNetwork(id, "key_mgmt", "WPA-PSK"); err != nil {
+		t.Fatal(err)
+	}
+	if got := srv.Network(id)["ssid"]; got != `"home"` {
+		t.Errorf("ssid was set to %s", got)
+	}
+
+	events, unsubscribe := conn.Subscribe()
+	defer unsubscribe()
+
+	if err := conn.SelectNetwork(id); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, events, "CONNECTED")
+
+	status, err := conn.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.WPAState() != "COMPLETED" || status.SSID() != "home" || status.KeyMgmt() != "WPA-PSK" {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	networks, err := conn.ListNetworks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(networks) != 1 || networks[0].SSID() != "home" || networks[0].Flags()[0] != "CURRENT" {
+		t.Errorf("unexpected networks %+v", networks)
+	}
+}
+
+func TestScan(t *testing.T) {
+	srv, conn := connect(t)
+	srv.SetBSSs(
+		wpatest.BSS{BSSID: "02:00:00:00:01:00", SSID: "home", Frequency: 2412, Signal: -40, Flags: "[WPA2-PSK-CCMP][ESS]"},
+		wpatest.BSS{BSSID: "02:00:00:00:02:00", SSID: "cafe", Frequency: 5180, Signal: -70, Flags: "[ESS]"},
+	)
+
+	events, unsubscribe := conn.Subscribe()
+	defer unsubscribe()
+
+	if err := conn.Scan(); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Scan(); err == nil {
+		t.Error("expected second scan to fail while busy")
+	}
+	waitEvent(t, events, "SCAN-RESULTS")
+
+	results, errs := conn.ScanResults()
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if len(results) != 2 || results[1].SSID() != "cafe" || results[0].Security()[0].KeyMgmt != wpasupplicant.PSK {
+		t.Errorf("unexpected results %+v", results)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	srv, conn := connect(t)
+
+	srv.Inject("RECONNECT", wpatest.Fault{Reply: "FAIL\n"})
+	if err := conn.Reconnect(); err == nil {
+		t.Error("expected injected failure")
+	}
+	if err := conn.Reconnect(); err != nil {
+		t.Errorf("fault was not cleared: %s", err)
+	}
+
+	srv.Inject("PING", wpatest.Fault{Drop: true})
+	if err := conn.Ping(); !errors.Is(err, wpasupplicant.ErrTimeout) {
+		t.Errorf("expected timeout, got %v", err)
+	}
+	if err := conn.Ping(); err != nil {
+		t.Errorf("ping after timeout: %s", err)
+	}
+
+	srv.SetBSSs(wpatest.BSS{BSSID: "02:00:00:00:01:00", SSID: "home", Frequency: 2412, Signal: -40})
+	srv.Inject("SCAN_RESULTS", wpatest.Fault{Truncate: 60})
+	if _, errs := conn.ScanResults(); len(errs) == 0 {
+		t.Error("expected truncated scan results to fail to parse")
+	}
+}
@@ -0,0 +1,219 @@
+package wpasupplicant
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// WPS event names.
+const (
+	EventWPSPBCActive       = "WPS-PBC-ACTIVE"
+	EventWPSSuccess         = "WPS-SUCCESS"
+	EventWPSFail            = "WPS-FAIL"
+	EventWPSTimeout         = "WPS-TIMEOUT"
+	EventWPSOverlapDetected = "WPS-OVERLAP-DETECTED"
+	EventWPSCredReceived    = "WPS-CRED-RECEIVED"
+)
+
+var (
+	// ErrWPSTimeout is returned when the WPS walk time elapsed without
+	// finding a registrar.
+	ErrWPSTimeout = errors.New("WPS timed out")
+
+	// ErrWPSOverlap is returned when more than one AP is in push button
+	// mode, so the one to use can't be determined.
+	ErrWPSOverlap = errors.New("WPS push button session overlap detected")
+)
+
+// WPSConfigError is a Configuration Error value from the WPS specification,
+// reported by WPS-FAIL events.
+type WPSConfigError int
+
+var wpsConfigErrors = []string{
+	"no error",
+	"OOB interface read error",
+	"decryption CRC failure",
+	"2.4 GHz channel not supported",
+	"5 GHz channel not supported",
+	"signal too weak",
+	"network authentication failure",
+	"network association failure",
+	"no DHCP response",
+	"failed DHCP config",
+	"IP address conflict",
+	"couldn't connect to registrar",
+	"multiple PBC sessions detected",
+	"rogue activity suspected",
+	"device busy",
+	"setup locked",
+	"message timeout",
+	"registration session timeout",
+	"device password authentication failure",
+	"60 GHz channel not supported",
+	"public key hash mismatch",
+}
+
+func (c WPSConfigError) String() string {
+	if c >= 0 && int(c) < len(wpsConfigErrors) {
+		return wpsConfigErrors[c]
+	}
+	return "config error " + strconv.Itoa(int(c))
+}
+
+// WPSErrorIndication is the reason wpa_supplicant gives for a WPS-FAIL,
+// in addition to the WPSConfigError.
+type WPSErrorIndication int
+
+var wpsErrorIndications = []string{
+	"no error",
+	"TKIP only prohibited",
+	"WEP prohibited",
+	"authentication failure",
+}
+
+func (r WPSErrorIndication) String() string {
+	if r >= 0 && int(r) < len(wpsErrorIndications) {
+		return wpsErrorIndications[r]
+	}
+	return "reason " + strconv.Itoa(int(r))
+}
+
+// WPSEvent is a WPS-* event.
+type WPSEvent struct {
+	// Event is one of the EventWPS constants.
+	Event string
+
+	// Msg is the WPS message the failure occurred in, for WPS-FAIL.
+	Msg int
+
+	// ConfigError and Reason describe the failure, for WPS-FAIL.
+	ConfigError WPSConfigError
+	Reason      WPSErrorIndication
+
+	// Credential is the raw credential attribute, for WPS-CRED-RECEIVED.
+	// It is only reported if wps_cred_processing is enabled.
+	Credential []byte
+}
+
+// ParseWPSEvent returns the WPSEvent for e, or false if e isn't a WPS
+// event.
+func ParseWPSEvent(e WPAEvent) (*WPSEvent, bool) {
+	switch e.Event {
+	case EventWPSPBCActive, EventWPSSuccess, EventWPSTimeout, EventWPSOverlapDetected:
+		return &WPSEvent{Event: e.Event}, true
+	case EventWPSFail:
+		w := &WPSEvent{Event: e.Event}
+		w.Msg, _ = strconv.Atoi(e.Arguments["msg"])
+		if v, err := strconv.Atoi(e.Arguments["config_error"]); err == nil {
+			w.ConfigError = WPSConfigError(v)
+		}
+		if v, err := strconv.Atoi(e.Arguments["reason"]); err == nil {
+			w.Reason = WPSErrorIndication(v)
+		}
+		return w, true
+	case EventWPSCredReceived:
+		w := &WPSEvent{Event: e.Event}
+		if f := splitEventFields(e.Line); len(f) > 1 {
+			w.Credential, _ = hex.DecodeString(f[1])
+		}
+		return w, true
+	}
+	return nil, false
+}
+
+// WPSError is returned when WPS fails with a WPS-FAIL event.
+type WPSError struct {
+	ConfigError WPSConfigError
+	Reason      WPSErrorIndication
+}
+
+func (err *WPSError) Error() string {
+	if err.Reason != 0 {
+		return fmt.Sprintf("WPS failed: %s (%s)", err.ConfigError, err.Reason)
+	}
+	return fmt.Sprintf("WPS failed: %s", err.ConfigError)
+}
+
+// connectedID extracts the network id from a CTRL-EVENT-CONNECTED event.
+var connectedID = regexp.MustCompile(`\[id=(\d+)`)
+
+func (uc *unixgram) WPSPushButton(ctx context.Context, bssid net.HardwareAddr) (int, error) {
+	cmd := "WPS_PBC"
+	if bssid != nil {
+		cmd += " " + bssid.String()
+	}
+
+	return uc.wps(ctx, cmd, func(resp []byte) bool {
+		return string(resp) == "OK\n"
+	})
+}
+
+func (uc *unixgram) WPSPin(ctx context.Context, bssid net.HardwareAddr, pin string) (int, error) {
+	if pin == "" {
+		return -1, errors.New("WPS PIN required")
+	}
+
+	target := "any"
+	if bssid != nil {
+		target = bssid.String()
+	}
+
+	// The reply is the PIN in use, without a trailing newline.
+	return uc.wps(ctx, "WPS_PIN "+target+" "+pin, func(resp []byte) bool {
+		return strings.TrimSuffix(string(resp), "\n") == pin
+	})
+}
+
+func (uc *unixgram) WPSCancel() error {
+	return uc.runCommand("WPS_CANCEL")
+}
+
+// wps starts a WPS operation with cmd and waits for it to complete and for
+// wpa_supplicant to connect to the network it received.  ok tells whether
+// the reply to cmd means success.  The operation is cancelled if ctx is
+// done first.
+func (uc *unixgram) wps(ctx context.Context, cmd string, ok func([]byte) bool) (int, error) {
+	events, unsubscribe := uc.Subscribe()
+	defer unsubscribe()
+
+	resp, err := uc.cmd(cmd)
+	if err != nil {
+		return -1, err
+	}
+	if !ok(resp) {
+		return -1, &ParseError{Line: string(resp)}
+	}
+
+	id := -1
+	succeeded := false
+	err = await(ctx, events, func(e WPAEvent) (bool, error) {
+		switch e.Event {
+		case EventWPSSuccess:
+			succeeded = true
+		case EventWPSTimeout:
+			return true, ErrWPSTimeout
+		case EventWPSOverlapDetected:
+			return true, ErrWPSOverlap
+		case EventWPSFail:
+			w, _ := ParseWPSEvent(e)
+			return true, &WPSError{ConfigError: w.ConfigError, Reason: w.Reason}
+		case "CONNECTED":
+			if m := connectedID.FindStringSubmatch(e.Line); succeeded && m != nil {
+				id, _ = strconv.Atoi(m[1])
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil && ctx.Err() != nil {
+		_ = uc.WPSCancel()
+	}
+
+	return id, err
+}
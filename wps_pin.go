@@ -0,0 +1,82 @@
+package wpasupplicant
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ErrWPSPinChecksum is returned by CheckWPSPin when wpa_supplicant rejects
+// the PIN's checksum digit.
+var ErrWPSPinChecksum = errors.New("invalid WPS PIN checksum")
+
+// GenerateWPSPin returns a random 8 digit WPS PIN with a valid checksum.
+func GenerateWPSPin() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(10000000))
+	if err != nil {
+		return "", err
+	}
+
+	pin := int(n.Int64())
+	return fmt.Sprintf("%07d%d", pin, wpsPinChecksum(pin)), nil
+}
+
+// ValidWPSPin reports whether pin is a valid WPS PIN: either 8 digits, the
+// last of which is the checksum of the others, or 4 digits, which have no
+// checksum.  Like wpa_supplicant, dashes and spaces are ignored, so
+// "1234-5670" is valid.
+func ValidWPSPin(pin string) bool {
+	pin = normalizeWPSPin(pin)
+	for _, c := range pin {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	switch len(pin) {
+	case 4:
+		return true
+	case 8:
+		v := 0
+		for _, c := range pin[:7] {
+			v = v*10 + int(c-'0')
+		}
+		return wpsPinChecksum(v) == int(pin[7]-'0')
+	}
+	return false
+}
+
+// normalizeWPSPin removes the separators users may type in a PIN.
+func normalizeWPSPin(pin string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(pin)
+}
+
+// wpsPinChecksum computes the checksum digit of the first 7 digits of a
+// PIN.
+func wpsPinChecksum(pin int) int {
+	accum := 0
+	for pin > 0 {
+		accum += 3 * (pin % 10)
+		pin /= 10
+		accum += pin % 10
+		pin /= 10
+	}
+	return (10 - accum%10) % 10
+}
+
+func (uc *unixgram) CheckWPSPin(pin string) (string, error) {
+	resp, err := uc.cmd("WPS_CHECK_PIN " + pin)
+	if err != nil {
+		return "", err
+	}
+
+	// A wrong checksum is reported as a *CommandError with the
+	// ErrWPSPinChecksum code.
+	reply := strings.TrimSuffix(string(resp), "\n")
+	if reply == "" {
+		return "", &ParseError{Line: string(resp)}
+	}
+	return reply, nil
+}
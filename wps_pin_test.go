@@ -0,0 +1,57 @@
+package wpasupplicant_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-laeo/wpasupplicant"
+)
+
+func TestValidWPSPin(t *testing.T) {
+	tests := map[string]bool{
+		"12345670":  true,
+		"1234-5670": true,
+		"1234 5670": true,
+		"12345678":  false,
+		"49226874":  true,
+		"1234":      true,
+		"12a4":      false,
+		"123456":    false,
+		"":          false,
+	}
+
+	for pin, expect := range tests {
+		if got := wpasupplicant.ValidWPSPin(pin); got != expect {
+			t.Errorf("ValidWPSPin(%q) = %v, expect %v", pin, got, expect)
+		}
+	}
+}
+
+func TestGenerateWPSPin(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		pin, err := wpasupplicant.GenerateWPSPin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pin) != 8 || !wpasupplicant.ValidWPSPin(pin) {
+			t.Fatalf("generated invalid PIN %q", pin)
+		}
+	}
+}
+
+func TestCheckWPSPin(t *testing.T) {
+	srv, conn := connect(t)
+	srv.Handle("WPS_CHECK_PIN", func(args string) (string, []string) {
+		if args == "12345678" {
+			return "FAIL-CHECKSUM\n", nil
+		}
+		return "12345670", nil
+	})
+
+	if pin, err := conn.CheckWPSPin("1234-5670"); err != nil || pin != "12345670" {
+		t.Errorf("got %q, %v", pin, err)
+	}
+	if _, err := conn.CheckWPSPin("12345678"); !errors.Is(err, wpasupplicant.ErrWPSPinChecksum) {
+		t.Errorf("expected checksum error, got %v", err)
+	}
+}
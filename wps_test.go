@@ -0,0 +1,100 @@
+package wpasupplicant_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-laeo/wpasupplicant"
+)
+
+func TestWPSPushButton(t *testing.T) {
+	srv, conn := connect(t)
+	srv.Handle("WPS_PBC", func(args string) (string, []string) {
+		return "OK\n", []string{
+			"WPS-PBC-ACTIVE ",
+			"WPS-CRED-RECEIVED ",
+			"WPS-SUCCESS ",
+			"CTRL-EVENT-CONNECTED - Connection to 02:00:00:00:01:00 completed [id=3 id_str=]",
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	id, err := conn.WPSPushButton(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 3 {
+		t.Errorf("got network id %d", id)
+	}
+}
+
+func TestWPSPin(t *testing.T) {
+	srv, conn := connect(t)
+	srv.Handle("WPS_PIN", func(args string) (string, []string) {
+		// wpa_supplicant replies with the bare PIN.
+		return "12345670", []string{
+			"WPS-SUCCESS ",
+			"CTRL-EVENT-CONNECTED - Connection to 02:00:00:00:01:00 completed [id=1 id_str=]",
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	id, err := conn.WPSPin(ctx, nil, "12345670")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 1 {
+		t.Errorf("got network id %d", id)
+	}
+}
+
+func TestWPSPinFailure(t *testing.T) {
+	srv, conn := connect(t)
+	srv.Handle("WPS_PIN", func(args string) (string, []string) {
+		return "12345670", []string{"WPS-FAIL msg=8 config_error=18 reason=3"}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	bssid, _ := net.ParseMAC("02:00:00:00:01:00")
+	_, err := conn.WPSPin(ctx, bssid, "12345670")
+
+	var wpsErr *wpasupplicant.WPSError
+	if !errors.As(err, &wpsErr) {
+		t.Fatalf("expected WPSError, got %v", err)
+	}
+	if wpsErr.ConfigError != 18 || wpsErr.Reason != 3 {
+		t.Errorf("got %+v", wpsErr)
+	}
+	if cmds := srv.Commands(); cmds[len(cmds)-1] != "WPS_PIN 02:00:00:00:01:00 12345670" {
+		t.Errorf("sent %q", cmds[len(cmds)-1])
+	}
+}
+
+func TestWPSCancelledByContext(t *testing.T) {
+	srv, conn := connect(t)
+	srv.Handle("WPS_PBC", func(args string) (string, []string) {
+		return "OK\n", nil
+	})
+	srv.Handle("WPS_CANCEL", func(args string) (string, []string) {
+		return "OK\n", nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := conn.WPSPushButton(ctx, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if cmds := srv.Commands(); cmds[len(cmds)-1] != "WPS_CANCEL" {
+		t.Errorf("WPS was not cancelled: %q", cmds)
+	}
+}